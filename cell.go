@@ -0,0 +1,37 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaff
+
+// Alignment is a hint for the alignment of a cell in tabular output.
+type Alignment int
+
+const (
+	// AlignDefault leaves the alignment to the formatter.
+	AlignDefault = Alignment(iota)
+	// AlignLeft aligns cells to the left.
+	AlignLeft
+	// AlignRight aligns cells to the right.
+	AlignRight
+)
+
+// CellFormatter is implemented by types that format their own cells in tabular output.
+// It takes precedence over fmt.Stringer and encoding.TextMarshaler.
+type CellFormatter interface {
+
+	// FormatCell returns the text of the cell along with a hint for its alignment.
+	FormatCell() (text string, align Alignment)
+}
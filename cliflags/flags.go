@@ -25,6 +25,7 @@ import (
 	lp "github.com/nehemming/yaff/cliflags/langpack"
 	"github.com/nehemming/yaff/csvformatter"
 	"github.com/nehemming/yaff/jsonformatter"
+	"github.com/nehemming/yaff/rowset"
 	"github.com/nehemming/yaff/templateformatter"
 	"github.com/nehemming/yaff/textformatter"
 	"github.com/nehemming/yaff/yamlformatter"
@@ -51,6 +52,40 @@ const (
 	FlagsColumnSeparator = "separator"
 	// FlagsTtyWidth width override for tty.
 	FlagsTtyWidth = "ttywidth"
+	// FlagsNoColor disables colored text output.
+	FlagsNoColor = "nocolor"
+	// FlagsReportingSort columns to sort rows by.
+	FlagsReportingSort = "sort"
+	// FlagsReportingFilter filter expression rows must match.
+	FlagsReportingFilter = "filter"
+	// FlagsReportingColumns ordered columns to output.
+	FlagsReportingColumns = "columns"
+	// FlagsNestedDepth depth of nested tables.
+	FlagsNestedDepth = "nested"
+	// FlagsGroupBy columns to group rows by.
+	FlagsGroupBy = "groupby"
+	// FlagsSubtotals outputs group subtotals.
+	FlagsSubtotals = "subtotals"
+	// FlagsLimit maximum rows output.
+	FlagsLimit = "limit"
+	// FlagsOffset rows skipped before output.
+	FlagsOffset = "offset"
+	// FlagsPageSize rows between repeated text headers.
+	FlagsPageSize = "pagesize"
+	// FlagsEnvelope wraps json and yaml rows with their total.
+	FlagsEnvelope = "envelope"
+	// FlagsTtyDetect detects the terminal width and piped output.
+	FlagsTtyDetect = "ttydetect"
+	// FlagsCSVTags names csv columns by csv tags.
+	FlagsCSVTags = "csvtags"
+	// FlagsQuoteAll quotes every csv field.
+	FlagsQuoteAll = "quoteall"
+	// FlagsCRLF ends csv lines with CRLF.
+	FlagsCRLF = "crlf"
+	// FlagsBOM writes a UTF-8 byte order mark before csv output.
+	FlagsBOM = "bom"
+	// FlagsNullText text of nil csv fields.
+	FlagsNullText = "nulltext"
 )
 
 const (
@@ -64,6 +99,8 @@ const (
 	ParamColumnSeparator = "separator"
 	// ParamTtyWidth is the tty width to use.
 	ParamTtyWidth = "ttywidth"
+	// ParamNoColor disables colored output.
+	ParamNoColor = "nocolor"
 )
 
 // AddFormattingFlags adds formatting flags to a flag set.
@@ -79,6 +116,23 @@ func AddFormattingFlags(flags *pflag.FlagSet) {
 	flags.String(FlagsReportingExclude, "", tf.Text(lp.FlagsReportingExclude))
 	flags.String(FlagsColumnSeparator, ",", tf.Text(lp.FlagsColumnSeparator))
 	flags.Int(FlagsTtyWidth, 0, tf.Text(lp.FlagsTtyWidth))
+	flags.Bool(FlagsNoColor, false, tf.Text(lp.FlagsNoColor))
+	flags.String(FlagsReportingSort, "", tf.Text(lp.FlagsReportingSort))
+	flags.String(FlagsReportingFilter, "", tf.Text(lp.FlagsReportingFilter))
+	flags.String(FlagsReportingColumns, "", tf.Text(lp.FlagsReportingColumns))
+	flags.Int(FlagsNestedDepth, 0, tf.Text(lp.FlagsNestedDepth))
+	flags.String(FlagsGroupBy, "", tf.Text(lp.FlagsGroupBy))
+	flags.Bool(FlagsSubtotals, false, tf.Text(lp.FlagsSubtotals))
+	flags.Int(FlagsLimit, 0, tf.Text(lp.FlagsLimit))
+	flags.Int(FlagsOffset, 0, tf.Text(lp.FlagsOffset))
+	flags.Int(FlagsPageSize, 0, tf.Text(lp.FlagsPageSize))
+	flags.Bool(FlagsEnvelope, false, tf.Text(lp.FlagsEnvelope))
+	flags.Bool(FlagsTtyDetect, false, tf.Text(lp.FlagsTtyDetect))
+	flags.Bool(FlagsCSVTags, false, tf.Text(lp.FlagsCSVTags))
+	flags.Bool(FlagsQuoteAll, false, tf.Text(lp.FlagsQuoteAll))
+	flags.Bool(FlagsCRLF, false, tf.Text(lp.FlagsCRLF))
+	flags.Bool(FlagsBOM, false, tf.Text(lp.FlagsBOM))
+	flags.String(FlagsNullText, "", tf.Text(lp.FlagsNullText))
 }
 
 // BindFormattingParamsToFlags binds a flags set to viper params.
@@ -103,6 +157,9 @@ func BindFormattingParamsToFlags(flags *pflag.FlagSet, config *viper.Viper, conf
 	if err := config.BindPFlag(configBase+ParamTtyWidth, flags.Lookup(FlagsTtyWidth)); err != nil {
 		return err
 	}
+	if err := config.BindPFlag(configBase+ParamNoColor, flags.Lookup(FlagsNoColor)); err != nil {
+		return err
+	}
 
 	config.SetDefault(configBase+ParamColumnSeparator, ",")
 
@@ -126,6 +183,11 @@ func GetFormmatterFromFlags(flags *pflag.FlagSet, v *viper.Viper, defaultFormat
 		return nil, nil, err
 	}
 
+	page, err := pageFromFlags(flags)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var formatOptions yaff.FormatOptions
 
 	// Bind format options from args
@@ -157,9 +219,22 @@ func GetFormmatterFromFlags(flags *pflag.FlagSet, v *viper.Viper, defaultFormat
 		option.ExcludeSet = mapFromList(list)
 		option.ColumnSeparator = v.GetString(configBase + ParamColumnSeparator)
 		option.TerminalWidth = v.GetInt(configBase + ParamTtyWidth)
+		option.AutoDetect, _ = flags.GetBool(FlagsTtyDetect)
+		option.NoColor = v.GetBool(configBase + ParamNoColor)
+		option.SortBy = sortKeysFromFlags(flags)
+		option.Columns = columnSpecsFromFlags(flags)
+		option.NestedDepth, _ = flags.GetInt(FlagsNestedDepth)
+		list, _ = flags.GetString(FlagsGroupBy)
+		option.GroupBy = listFromString(list)
+		option.Subtotals, _ = flags.GetBool(FlagsSubtotals)
+		option.Offset, option.Limit = page.Offset, page.Limit
+		option.PageSize, _ = flags.GetInt(FlagsPageSize)
+		if option.PageSize < 0 {
+			return nil, nil, lpax.Errorf(lp.ErrorPageLessThanZero, FlagsPageSize, option.PageSize)
+		}
 		formatOptions = option
 
-	case jsonformatter.JSON:
+	case jsonformatter.JSON, jsonformatter.JSONLines, jsonformatter.NDJSON:
 		option := jsonformatter.NewOptions()
 
 		indent := v.GetInt(configBase + ParamsReportingIndent)
@@ -168,21 +243,95 @@ func GetFormmatterFromFlags(flags *pflag.FlagSet, v *viper.Viper, defaultFormat
 		}
 
 		option.Indent = indent
+		option.Offset, option.Limit = page.Offset, page.Limit
+		option.Envelope, _ = flags.GetBool(FlagsEnvelope)
 		formatOptions = option
 
-	case csvformatter.CSV:
+	case csvformatter.CSV, csvformatter.TSV:
 		option := csvformatter.NewOptions()
-		option.ColumnSeparator = v.GetString(configBase + ParamColumnSeparator)
+		if format == csvformatter.TSV {
+			option = csvformatter.NewTSVOptions()
+		}
+
+		// Tab separated values keep their separator unless it is set on the command line
+		if format == csvformatter.CSV || flags.Changed(FlagsColumnSeparator) {
+			option.ColumnSeparator = v.GetString(configBase + ParamColumnSeparator)
+		}
+		option.SortBy = sortKeysFromFlags(flags)
+		option.Columns = columnSpecsFromFlags(flags)
+		option.Offset, option.Limit = page.Offset, page.Limit
+		list, _ := flags.GetString(FlagsReportingInclude)
+		option.ColumnSet = mapFromList(list)
+		list, _ = flags.GetString(FlagsReportingExclude)
+		option.ExcludeSet = mapFromList(list)
+		option.CSVTags, _ = flags.GetBool(FlagsCSVTags)
+		option.QuoteAll, _ = flags.GetBool(FlagsQuoteAll)
+		option.CRLF, _ = flags.GetBool(FlagsCRLF)
+		option.BOM, _ = flags.GetBool(FlagsBOM)
+		option.NullText, _ = flags.GetString(FlagsNullText)
 		formatOptions = option
 
 	case yamlformatter.YAML:
-		fallthrough
+		option := yamlformatter.NewOptions()
+		option.Offset, option.Limit = page.Offset, page.Limit
+		option.Envelope, _ = flags.GetBool(FlagsEnvelope)
+		formatOptions = option
+
 	default:
 	}
 
+	if expression, _ := flags.GetString(FlagsReportingFilter); expression != "" {
+		filter, err := rowset.ParseFilter(expression)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		formatter = rowset.NewFilterFormatter(formatter, filter)
+	}
+
 	return formatter, formatOptions, nil
 }
 
+// pageFromFlags returns the rows selected by the limit and offset flags.
+func pageFromFlags(flags *pflag.FlagSet) (rowset.Page, error) {
+	var page rowset.Page
+
+	page.Limit, _ = flags.GetInt(FlagsLimit)
+	if page.Limit < 0 {
+		return page, lpax.Errorf(lp.ErrorPageLessThanZero, FlagsLimit, page.Limit)
+	}
+
+	page.Offset, _ = flags.GetInt(FlagsOffset)
+	if page.Offset < 0 {
+		return page, lpax.Errorf(lp.ErrorPageLessThanZero, FlagsOffset, page.Offset)
+	}
+
+	return page, nil
+}
+
+func sortKeysFromFlags(flags *pflag.FlagSet) []rowset.SortKey {
+	spec, _ := flags.GetString(FlagsReportingSort)
+	return rowset.ParseSortKeys(spec)
+}
+
+func columnSpecsFromFlags(flags *pflag.FlagSet) []rowset.ColumnSpec {
+	spec, _ := flags.GetString(FlagsReportingColumns)
+	return rowset.ParseColumnSpecs(spec)
+}
+
+// listFromString splits a comma separated list, in order.
+func listFromString(list string) []string {
+	var items []string
+
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.Trim(s, " "); s != "" {
+			items = append(items, s)
+		}
+	}
+
+	return items
+}
+
 func mapFromList(list string) map[string]bool {
 	m := make(map[string]bool)
 	items := strings.Split(list, ",")
@@ -17,8 +17,11 @@ limitations under the License.
 package cliflags
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/nehemming/lpax"
+	lp "github.com/nehemming/yaff/cliflags/langpack"
 	"github.com/nehemming/yaff/csvformatter"
 	"github.com/nehemming/yaff/jsonformatter"
 	"github.com/nehemming/yaff/templateformatter"
@@ -127,12 +130,48 @@ func TestGetFormmatterFromFlagsYAML(t *testing.T) {
 		t.Error("err:", err)
 	}
 
-	if fo != nil {
+	if _, ok := fo.(yamlformatter.Options); !ok {
 		t.Error("fo:", fo)
 		return
 	}
 }
 
+func TestGetFormmatterFromFlagsPage(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+
+	AddFormattingFlags(flags)
+
+	_ = flags.Parse([]string{"--limit", "10", "--offset", "20", "--pagesize", "5", "--envelope"})
+
+	_, fo, err := GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	textOut := fo.(textformatter.Options)
+	if textOut.Limit != 10 || textOut.Offset != 20 || textOut.PageSize != 5 {
+		t.Error("text page:", textOut.Limit, textOut.Offset, textOut.PageSize)
+	}
+
+	_, fo, err = GetFormmatterFromFlags(flags, v, jsonformatter.JSON, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	jOpt := fo.(jsonformatter.Options)
+	if jOpt.Limit != 10 || jOpt.Offset != 20 || !jOpt.Envelope {
+		t.Error("json page:", jOpt.Limit, jOpt.Offset, jOpt.Envelope)
+	}
+
+	_ = flags.Parse([]string{"--limit", "-1"})
+
+	_, _, err = GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg")
+	if err == nil || err.Error() != lpax.Sprintf(lp.ErrorPageLessThanZero, FlagsLimit, -1) {
+		t.Error("err:", err)
+	}
+}
+
 func TestGetFormmatterFromFlagsCSVFmt(t *testing.T) {
 	flags := new(pflag.FlagSet)
 	v := viper.New()
@@ -154,6 +193,32 @@ func TestGetFormmatterFromFlagsCSVFmt(t *testing.T) {
 	}
 }
 
+func TestGetFormmatterFromFlagsTSV(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+
+	AddFormattingFlags(flags)
+	if err := BindFormattingParamsToFlags(flags, v, "cfg"); err != nil {
+		t.Error("err:", err)
+	}
+
+	_ = flags.Parse([]string{"--quoteall", "--crlf", "--bom", "--nulltext", "NULL"})
+
+	_, fo, err := GetFormmatterFromFlags(flags, v, csvformatter.TSV, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	csvOpt := fo.(csvformatter.Options)
+	if csvOpt.ColumnSeparator != "\t" {
+		t.Errorf("ColumnSeparator: %q", csvOpt.ColumnSeparator)
+	}
+
+	if !csvOpt.QuoteAll || !csvOpt.CRLF || !csvOpt.BOM || csvOpt.NullText != "NULL" {
+		t.Error("dialect:", csvOpt.QuoteAll, csvOpt.CRLF, csvOpt.BOM, csvOpt.NullText)
+	}
+}
+
 func TestGetFormmatterFromFlagsTextFmt(t *testing.T) {
 	flags := new(pflag.FlagSet)
 	v := viper.New()
@@ -213,3 +278,127 @@ func TestBindFormattingParamsToFlags(t *testing.T) {
 		t.Error("format:", fmt)
 	}
 }
+
+func TestGetFormmatterFromFlagsTextNoColor(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+	AddFormattingFlags(flags)
+	if err := BindFormattingParamsToFlags(flags, v, "cfg"); err != nil {
+		t.Error("err:", err)
+	}
+
+	_ = flags.Parse([]string{"--nocolor"})
+	_, fo, err := GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	textOut := fo.(textformatter.Options)
+
+	if !textOut.NoColor {
+		t.Error("NoColor:", textOut.NoColor)
+	}
+}
+
+func TestGetFormmatterFromFlagsJSONLines(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+	AddFormattingFlags(flags)
+	if err := BindFormattingParamsToFlags(flags, v, "cfg"); err != nil {
+		t.Error("err:", err)
+	}
+
+	_ = flags.Parse([]string{"--format", "jsonl"})
+	f, _, err := GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	if f == nil {
+		t.Error("no formatter")
+	}
+}
+
+func TestGetFormmatterFromFlagsSort(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+	AddFormattingFlags(flags)
+
+	_ = flags.Parse([]string{"--sort", "Name,-Size"})
+	_, fo, err := GetFormmatterFromFlags(flags, v, csvformatter.CSV, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	csvOpt := fo.(csvformatter.Options)
+
+	if len(csvOpt.SortBy) != 2 || csvOpt.SortBy[0].Column != "Name" || !csvOpt.SortBy[1].Descending {
+		t.Error("SortBy:", csvOpt.SortBy)
+	}
+}
+
+func TestGetFormmatterFromFlagsFilter(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+	AddFormattingFlags(flags)
+	if err := BindFormattingParamsToFlags(flags, v, "cfg"); err != nil {
+		t.Error("err:", err)
+	}
+
+	_ = flags.Parse([]string{"--format", "csv", "--filter", `Name == "b"`})
+	f, fo, err := GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg")
+	if err != nil {
+		t.Fatal("err:", err)
+	}
+
+	type row struct {
+		Name string
+	}
+
+	var buf bytes.Buffer
+	if err := f.Format(&buf, fo, []row{{Name: "a"}, {Name: "b"}}); err != nil {
+		t.Error("err:", err)
+	}
+
+	if buf.String() != "Name\nb\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestGetFormmatterFromFlagsFilterInvalid(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+	AddFormattingFlags(flags)
+
+	_ = flags.Parse([]string{"--filter", `Name ==`})
+	if _, _, err := GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGetFormmatterFromFlagsColumns(t *testing.T) {
+	flags := new(pflag.FlagSet)
+	v := viper.New()
+	AddFormattingFlags(flags)
+
+	_ = flags.Parse([]string{"--columns", "Name,Status as State", "--nested", "2", "--groupby", "Status, Zone",
+		"--subtotals"})
+	_, fo, err := GetFormmatterFromFlags(flags, v, textformatter.Text, "cfg")
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	textOpt := fo.(textformatter.Options)
+
+	if len(textOpt.Columns) != 2 || textOpt.Columns[1].Alias != "State" {
+		t.Error("Columns:", textOpt.Columns)
+	}
+
+	if textOpt.NestedDepth != 2 {
+		t.Error("NestedDepth:", textOpt.NestedDepth)
+	}
+
+	if len(textOpt.GroupBy) != 2 || textOpt.GroupBy[1] != "Zone" || !textOpt.Subtotals {
+		t.Error("GroupBy:", textOpt.GroupBy, textOpt.Subtotals)
+	}
+}
@@ -74,18 +74,55 @@ const (
 	FlagsColumnSeparator
 	// FlagsTtyWidth terminal width.
 	FlagsTtyWidth
+	// FlagsNoColor disable color.
+	FlagsNoColor
+	// FlagsReportingSort cli arg for sort columns.
+	FlagsReportingSort
+	// FlagsReportingFilter cli arg for row filter.
+	FlagsReportingFilter
+	// FlagsReportingColumns cli arg for ordered columns.
+	FlagsReportingColumns
+	// FlagsNestedDepth cli arg for nested table depth.
+	FlagsNestedDepth
+	// FlagsGroupBy cli arg for group by columns.
+	FlagsGroupBy
+	// FlagsSubtotals cli arg for group subtotals.
+	FlagsSubtotals
+	// FlagsLimit cli arg for the maximum rows output.
+	FlagsLimit
+	// FlagsOffset cli arg for the rows skipped.
+	FlagsOffset
+	// FlagsPageSize cli arg for rows between repeated headers.
+	FlagsPageSize
+	// FlagsEnvelope cli arg to wrap rows with their total.
+	FlagsEnvelope
+	// FlagsTtyDetect cli arg to detect the terminal.
+	FlagsTtyDetect
+	// FlagsCSVTags cli arg to name csv columns by csv tags.
+	FlagsCSVTags
+	// FlagsQuoteAll cli arg to quote every csv field.
+	FlagsQuoteAll
+	// FlagsCRLF cli arg to end csv lines with CRLF.
+	FlagsCRLF
+	// FlagsBOM cli arg to write a byte order mark.
+	FlagsBOM
+	// FlagsNullText cli arg for the text of nil csv fields.
+	FlagsNullText
 
 	// ErrorIndentLessThanZero bad indent.
 	ErrorIndentLessThanZero
 
 	// ErrorTemplateAndTemplateFileSet bad template args.
 	ErrorTemplateAndTemplateFileSet
+
+	// ErrorPageLessThanZero bad limit, offset or page size.
+	ErrorPageLessThanZero
 )
 
 var languagePack = lpax.TextMap{
 
-	FlagsReportingFormat:            "output format (csv|json|yaml|text). Default is text",
-	FlagsReportingStyle:             "output style (plain|grid|aligned|md) Default for text is aligned",
+	FlagsReportingFormat:            "output format (csv|tsv|json|jsonl|yaml|text|template). Default is text",
+	FlagsReportingStyle:             "output style (plain|grid|aligned|md|light|heavy|double|rounded|vertical) Default for text is aligned",
 	FlagsReportingTemplate:          "template string to use for text output. Uses Go templating syntax",
 	FlagsReportingTemplateFile:      "template file path. File must ne in GO templating syntax",
 	FlagsReportingIndent:            "indenting to use with JSON formating, 0 for single line output",
@@ -93,8 +130,26 @@ var languagePack = lpax.TextMap{
 	FlagsReportingExclude:           "columns to exclude from output",
 	FlagsTtyWidth:                   "override to width of terminal for tty output",
 	FlagsColumnSeparator:            "field separator for csv files",
+	FlagsNoColor:                    "disable colored text output",
+	FlagsReportingSort:              "columns to sort rows by, prefix a column with - to sort descending",
+	FlagsReportingFilter:            "filter expression rows must match, i.e. 'Status == \"failed\" && Retries > 2'",
+	FlagsReportingColumns:           "ordered columns to output, rename a column using as, i.e. 'Name,Status as State'",
+	FlagsNestedDepth:                "depth of nested tables output for lists of records, 0 omits them",
+	FlagsGroupBy:                    "columns to group text rows by",
+	FlagsSubtotals:                  "output subtotals of the aggregated columns for each group",
+	FlagsLimit:                      "maximum number of rows to output, 0 for all rows",
+	FlagsOffset:                     "number of rows to skip before output starts",
+	FlagsPageSize:                   "repeat the text header every n rows, 0 writes it once",
+	FlagsEnvelope:                   "wrap json and yaml rows in an object holding their total, offset and items",
+	FlagsTtyDetect:                  "detect the terminal width, writing plain text without color when output is piped",
+	FlagsCSVTags:                    "name csv columns using csv tags rather than the text columns",
+	FlagsQuoteAll:                   "quote every csv field",
+	FlagsCRLF:                       "end csv lines with CRLF, as expected by Excel",
+	FlagsBOM:                        "write a UTF-8 byte order mark before csv output",
+	FlagsNullText:                   "text output in csv for nil pointers, slices and maps",
 	ErrorIndentLessThanZero:         "Indent (%d) is less than zero",
 	ErrorTemplateAndTemplateFileSet: "%s and %s are both set, only one can be applied at once",
+	ErrorPageLessThanZero:           "%s (%d) is less than zero",
 }
 
 func init() {
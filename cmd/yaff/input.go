@@ -0,0 +1,220 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/csvformatter"
+	"github.com/nehemming/yaff/jsonformatter"
+	"github.com/nehemming/yaff/rowset"
+	"github.com/nehemming/yaff/textformatter"
+	"github.com/nehemming/yaff/yamlformatter"
+)
+
+// stdinName is the file name used for stdin.
+const stdinName = "-"
+
+// extensionFormats maps file extensions to their format.
+var extensionFormats = map[string]yaff.Format{
+	".csv":    csvformatter.CSV,
+	".tsv":    csvformatter.TSV,
+	".json":   jsonformatter.JSON,
+	".jsonl":  jsonformatter.JSONLines,
+	".ndjson": jsonformatter.NDJSON,
+	".yaml":   yamlformatter.YAML,
+	".yml":    yamlformatter.YAML,
+	".txt":    textformatter.Text,
+}
+
+// input holds the records read from the input files.
+type input struct {
+	format yaff.Format
+	// separator separates the columns of plain text input.
+	separator string
+	records   []interface{}
+	// header holds the column names of tabular input in the order first read.
+	header []string
+	seen   map[string]bool
+}
+
+func newInput(format yaff.Format, separator string) *input {
+	return &input{
+		format:    format,
+		separator: separator,
+		records:   make([]interface{}, 0, 64),
+		seen:      make(map[string]bool),
+	}
+}
+
+// readAll reads the records of each file, stdin is read if there are no files.
+// Reading stops with the context's error once ctx is done.
+func (in *input) readAll(ctx context.Context, files []string, stdin io.Reader) error {
+	if len(files) == 0 {
+		files = []string{stdinName}
+	}
+
+	for _, name := range files {
+		if err := in.readFile(ctx, name, stdin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readFile reads the records of a file, or stdin, decoding them as they are read.
+func (in *input) readFile(ctx context.Context, name string, stdin io.Reader) error {
+	reader := stdin
+	if name != stdinName {
+		file, err := os.Open(filepath.Clean(name))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		reader = file
+	}
+
+	reader = &contextReader{ctx: ctx, reader: reader}
+
+	format := in.fileFormat(name)
+
+	dec, err := yaff.Formatters().GetDecoder(format)
+	if err != nil {
+		return err
+	}
+
+	if !isTabular(format) {
+		return in.decode(name, format, dec, reader, &in.records)
+	}
+
+	// Tabular input is read as maps keyed by the header
+	var records []map[string]interface{}
+	if err := in.decode(name, format, dec, reader, &records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		in.records = append(in.records, record)
+	}
+
+	if hd, ok := dec.(yaff.HeaderDecoder); ok {
+		in.addHeader(hd.Header())
+	}
+
+	return nil
+}
+
+// contextReader fails reads once its context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cr.reader.Read(p)
+}
+
+// decode decodes the input of a file, naming the file in any error.
+func (in *input) decode(name string, format yaff.Format, dec yaff.Decoder, reader io.Reader, target interface{}) error {
+	if err := dec.Decode(reader, in.decodeOptions(format), target); err != nil {
+		return &os.PathError{Op: "decode", Path: name, Err: err}
+	}
+	return nil
+}
+
+// fileFormat returns the input format of a file.
+func (in *input) fileFormat(name string) yaff.Format {
+	if in.format != "" {
+		return in.format
+	}
+
+	if format, ok := extensionFormats[strings.ToLower(filepath.Ext(name))]; ok {
+		return format
+	}
+
+	return jsonformatter.JSON
+}
+
+// decodeOptions returns the options used to decode the format, text is read as plain text.
+func (in *input) decodeOptions(format yaff.Format) yaff.FormatOptions {
+	if format == textformatter.Text {
+		options := textformatter.NewOptions()
+		options.Style = textformatter.Plain
+		options.ColumnSeparator = in.separator
+		return options
+	}
+	return nil
+}
+
+// isTabular returns true if the format has a header naming its columns.
+func isTabular(format yaff.Format) bool {
+	return format == csvformatter.CSV || format == csvformatter.TSV || format == textformatter.Text
+}
+
+// addHeader adds the column names of the header of tabular input.
+func (in *input) addHeader(header []string) {
+	for _, name := range header {
+		if !in.seen[name] {
+			in.seen[name] = true
+			in.header = append(in.header, name)
+		}
+	}
+}
+
+// orderColumns sets the columns of text and csv output to the header order of tabular input,
+// keeping only the columns selected by the column set and exclude set.
+func (in *input) orderColumns(options yaff.FormatOptions) yaff.FormatOptions {
+	switch o := options.(type) {
+	case textformatter.Options:
+		o.Columns = in.columns(o.ColumnSet, o.ExcludeSet)
+		return o
+
+	case csvformatter.Options:
+		o.Columns = in.columns(o.ColumnSet, o.ExcludeSet)
+		return o
+
+	default:
+		return options
+	}
+}
+
+// columns returns the column specs of the header names selected.
+func (in *input) columns(columnSet, excludeSet map[string]bool) []rowset.ColumnSpec {
+	var specs []rowset.ColumnSpec
+
+	for _, name := range in.header {
+		key := strings.ToLower(name)
+		if (len(columnSet) > 0 && !columnSet[key]) || excludeSet[key] {
+			continue
+		}
+
+		specs = append(specs, rowset.ColumnSpec{Field: name})
+	}
+
+	return specs
+}
@@ -0,0 +1,98 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command yaff converts JSON, YAML or CSV input to any format registered with yaff.
+//
+// Usage:
+//
+//	yaff [flags] [file ...]
+//
+// Records are read from each file, or stdin if there are none, and written to stdout as a single
+// data set using the formatting flags, i.e. "yaff --format text --style grid services.json".
+// The input format is taken from the --input flag, or each file's extension, defaulting to JSON.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/cliflags"
+	"github.com/nehemming/yaff/textformatter"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// flagInput is the format of the input.
+const flagInput = "input"
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// An interrupt cancels reading and formatting, a second one ends the process
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		cancel()
+	}()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run converts the input files, or stdin, using the command line args.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := pflag.NewFlagSet("yaff", pflag.ContinueOnError)
+	cliflags.AddFormattingFlags(flags)
+	flags.StringP(flagInput, "i", "", "input format (csv|tsv|json|jsonl|yaml|text). Default is taken from the file extension, or json. Text input is plain text")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	v := viper.New()
+	if err := cliflags.BindFormattingParamsToFlags(flags, v, ""); err != nil {
+		return err
+	}
+
+	formatter, options, err := cliflags.GetFormmatterFromFlags(flags, v, textformatter.Text, "")
+	if err != nil {
+		return err
+	}
+
+	inputFormat, _ := flags.GetString(flagInput)
+
+	// Plain text input is separated by the same separator as plain text output
+	in := newInput(yaff.Format(inputFormat), v.GetString(cliflags.ParamColumnSeparator))
+	if err := in.readAll(ctx, flags.Args(), stdin); err != nil {
+		return err
+	}
+
+	// Columns read from headers keep their order unless the columns are set on the command line
+	if !flags.Changed(cliflags.FlagsReportingColumns) {
+		options = in.orderColumns(options)
+	}
+
+	return yaff.WithContext(formatter).FormatContext(ctx, stdout, options, in.records)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff/langpack"
+)
+
+func convert(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(stdin), &buf)
+
+	return buf.String(), err
+}
+
+func TestJSONToCSV(t *testing.T) {
+	got, err := convert(t, `[{"name":"a","n":1},{"name":"b","n":2.5}]`, "--format", "csv")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "n,name\n1,a\n2.5,b\n", got)
+}
+
+func TestJSONLargeIntegers(t *testing.T) {
+	got, err := convert(t, `[{"id":1000000,"size":12345678901234567,"ratio":0.5}]`, "--format", "text", "--style", "plain")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "id,ratio,size\n1000000,0.5,12345678901234567\n", got)
+
+	got, err = convert(t, `{"id":1000000,"tags":[20000000]}`, "--format", "yaml")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "- id: 1000000\n  tags:\n    - 20000000\n\n", got)
+}
+
+func TestCSVToJSONLines(t *testing.T) {
+	got, err := convert(t, "Name,Zone\nweb,\"eu, west\"\n", "-i", "csv", "--format", "jsonl")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "{\"Name\":\"web\",\"Zone\":\"eu, west\"}\n", got)
+}
+
+func TestCSVKeepsColumnOrder(t *testing.T) {
+	got, err := convert(t, "Zone,Name,Size\neu,web,10\nus,db,200\n", "-i", "csv", "--format", "csv", "--excludecols", "size")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Zone,Name\neu,web\nus,db\n", got)
+
+	got, err = convert(t, "Zone,Name,Size\neu,web,10\n", "-i", "csv", "--format", "csv", "--columns", "Name,Size")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Name,Size\nweb,10\n", got)
+}
+
+func TestTextKeepsColumnOrder(t *testing.T) {
+	got, err := convert(t, "Zone,Name\neu,web\n", "-i", "text", "--format", "csv")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Zone,Name\neu,web\n", got)
+}
+
+func TestYAMLToText(t *testing.T) {
+	got, err := convert(t, "- {name: a}\n---\n- {name: b}\n", "-i", "yaml", "--style", "plain")
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "name\na\nb\n", got)
+}
+
+func TestFilesByExtension(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yaff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.tsv":   "Name\tSize\nweb\t10\n",
+		"b.jsonl": "{\"Name\":\"db\",\"Size\":200}\n",
+	}
+
+	args := []string{"--format", "csv", "--filter", "Name == \"db\" || Size == \"10\""}
+	for _, name := range []string{"a.tsv", "b.jsonl"} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(files[name]), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, path)
+	}
+
+	got, err := convert(t, "", args...)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Name,Size\nweb,10\ndb,200\n", got)
+}
+
+func TestDecodeErrorNamesFile(t *testing.T) {
+	_, err := convert(t, "[{\"name\":}]")
+
+	if err == nil || err.Error() != "decode -: Line 1, column 10: invalid character '}' looking for beginning of value" {
+		t.Error("err:", err)
+	}
+}
+
+func TestUnknownInputFormat(t *testing.T) {
+	_, err := convert(t, "", "-i", "xml")
+
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorDecodingNotSupported, "xml") {
+		t.Error("err:", err)
+	}
+}
+
+func TestCanceledInput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	err := run(ctx, []string{"--format", "csv"}, strings.NewReader(`[{"name":"a"}]`), &buf)
+
+	if !errors.Is(err, context.Canceled) || buf.Len() != 0 {
+		t.Errorf("err %v output %q", err, buf.String())
+	}
+}
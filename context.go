@@ -0,0 +1,66 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaff
+
+import (
+	"context"
+	"io"
+)
+
+// contextWriter fails writes once its context is done.
+type contextWriter struct {
+	ctx    context.Context
+	writer io.Writer
+}
+
+// NewContextWriter returns a writer that writes to writer until ctx is done,
+// after which every write fails with the context's error.
+func NewContextWriter(ctx context.Context, writer io.Writer) io.Writer {
+	return &contextWriter{ctx: ctx, writer: writer}
+}
+
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cw.writer.Write(p)
+}
+
+// contextFormatter adapts a Formatter that is not context aware.
+type contextFormatter struct {
+	Formatter
+}
+
+func (cf contextFormatter) FormatContext(ctx context.Context, writer io.Writer, options FormatOptions, data ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return cf.Format(NewContextWriter(ctx, writer), options, data...)
+}
+
+// WithContext returns a context aware version of the formatter.
+// Formatters that do not implement ContextFormatter are adapted to check
+// the context before formatting and before every write to the writer.
+func WithContext(formatter Formatter) ContextFormatter {
+	if cf, ok := formatter.(ContextFormatter); ok {
+		return cf
+	}
+
+	return contextFormatter{formatter}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestContextWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var buf bytes.Buffer
+	w := NewContextWriter(ctx, &buf)
+
+	if _, err := w.Write([]byte("one")); err != nil {
+		t.Errorf("Write error %v", err)
+	}
+
+	cancel()
+
+	if _, err := w.Write([]byte("two")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Write error %v", err)
+	}
+
+	if buf.String() != "one" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+type writingFormatter struct{}
+
+func (f *writingFormatter) Format(writer io.Writer, options FormatOptions, data ...interface{}) error {
+	for range data {
+		if _, err := writer.Write([]byte("row\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWithContextAdaptsFormatter(t *testing.T) {
+	cf := WithContext(&writingFormatter{})
+
+	var buf bytes.Buffer
+	if err := cf.FormatContext(context.Background(), &buf, nil, 1, 2); err != nil {
+		t.Errorf("Format error %v", err)
+	}
+
+	if buf.String() != "row\nrow\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	buf.Reset()
+	if err := cf.FormatContext(ctx, &buf, nil, 1, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("Format error %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+type testContextFormatter struct {
+	testFormatter
+}
+
+func (f *testContextFormatter) FormatContext(ctx context.Context, writer io.Writer, options FormatOptions, data ...interface{}) error {
+	return nil
+}
+
+func TestWithContextKeepsContextFormatter(t *testing.T) {
+	f := &testContextFormatter{}
+
+	if cf := WithContext(f); cf != f {
+		t.Error("context formatter was adapted")
+	}
+}
+
+func TestGetContextFormatter(t *testing.T) {
+	reg := NewRegistry()
+
+	testFormat := Format("test")
+
+	reg.Register(testFormat, func() (Formatter, error) {
+		return &writingFormatter{}, nil
+	})
+
+	cf, err := reg.GetContextFormatter(testFormat)
+	if err != nil {
+		t.Errorf("Error get %v", err)
+	}
+
+	if cf == nil {
+		t.Error("No context formatter")
+	}
+
+	if _, err := reg.GetContextFormatter(Format("unknown")); err == nil {
+		t.Error("No error for unknown formatter")
+	}
+}
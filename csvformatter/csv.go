@@ -18,29 +18,69 @@ limitations under the License.
 package csvformatter
 
 import (
-	"encoding/csv"
+	"context"
 	"io"
+	"reflect"
 
 	"github.com/gocarina/gocsv"
 	"github.com/nehemming/lpax"
 	"github.com/nehemming/yaff"
 	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
 )
 
-// CSV format.
-const CSV = yaff.Format("csv")
+const (
+	// CSV format.
+	CSV = yaff.Format("csv")
+
+	// TSV tab separated values format, its default options are NewTSVOptions.
+	TSV = yaff.Format("tsv")
+)
 
 // NewFormatter return a new formatter.
 func NewFormatter() (yaff.Formatter, error) {
-	return &formatter{}, nil
+	return &formatter{format: CSV}, nil
+}
+
+// NewTSVFormatter return a new tab separated values formatter.
+func NewTSVFormatter() (yaff.Formatter, error) {
+	return &formatter{format: TSV}, nil
 }
 
-type formatter struct{}
+type formatter struct {
+	format yaff.Format
+}
 
 // Options for the JSON formatter.
 type Options struct {
 	IncludeHeader   bool
 	ColumnSeparator string
+	// SortBy is an ordered list of columns to sort rows by.
+	SortBy []rowset.SortKey
+	// Columns is an ordered list of the columns to output and their headers.
+	// When set it takes precedence over ColumnSet and ExcludeSet.
+	Columns []rowset.ColumnSpec
+	// ColumnSet and ExcludeSet select the columns output by name, as they do for the text formatter.
+	ColumnSet  map[string]bool
+	ExcludeSet map[string]bool
+	// CSVTags marshals rows with gocsv, naming columns by their csv tags, rather than using the
+	// tabular tags and column plan of the text formatter.  Every column gocsv outputs is written,
+	// Columns, ColumnSet, ExcludeSet, Aggregates and NullText are ignored.
+	CSVTags bool
+	// Aggregates maps column header names to the aggregate written in a final totals row.
+	Aggregates map[string]rowset.Aggregate
+	// Offset is the number of rows of each data item skipped, after sorting, before output starts.
+	Offset int
+	// Limit is the maximum number of rows of each data item output, 0 for no limit.
+	Limit int
+	// QuoteAll quotes every field, not just those containing separators, quotes or line breaks.
+	QuoteAll bool
+	// CRLF terminates lines with \r\n, as expected by Excel, rather than \n.
+	CRLF bool
+	// BOM writes a UTF-8 byte order mark before the first line.
+	BOM bool
+	// NullText is the text output for nil pointers, slices and maps.
+	NullText string
 }
 
 // NewOptions return new options.
@@ -51,45 +91,152 @@ func NewOptions() Options {
 	}
 }
 
-func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+// NewTSVOptions return new options for tab separated values.
+func NewTSVOptions() Options {
+	options := NewOptions()
+	options.ColumnSeparator = "\t"
+	return options
+}
+
+func getOptions(options yaff.FormatOptions, format yaff.Format) (Options, error) {
 	if options == nil {
-		options = NewOptions()
+		if format == TSV {
+			options = NewTSVOptions()
+		} else {
+			options = NewOptions()
+		}
 	}
 
 	// convert options type
 	csvOptions, ok := options.(Options)
 	if !ok {
-		return lpax.Errorf(langpack.ErrorInvalidOptionType, options, CSV)
+		return csvOptions, lpax.Errorf(langpack.ErrorInvalidOptionType, options, format)
 	}
 
-	// Set up writer with options
-	out := gocsv.NewSafeCSVWriter(csv.NewWriter(writer))
-
-	if csvOptions.ColumnSeparator != "" {
-		out.Comma = ([]rune(csvOptions.ColumnSeparator))[0]
-	} else {
-		out.Comma = ','
+	if err := validSeparator(csvOptions.ColumnSeparator); err != nil {
+		return csvOptions, err
 	}
 
-	// Set header mode
-	var marshaller func(interface{}, gocsv.CSVWriter) error
-	if csvOptions.IncludeHeader {
-		marshaller = gocsv.MarshalCSV
-	} else {
-		marshaller = gocsv.MarshalCSVWithoutHeaders
+	return csvOptions, nil
+}
+
+// page returns the rows selected by the options.
+func (options Options) page() rowset.Page {
+	return rowset.Page{Offset: options.Offset, Limit: options.Limit}
+}
+
+// newCSVWriter sets up a csv writer with the options.
+func newCSVWriter(writer io.Writer, csvOptions Options) gocsv.CSVWriter {
+	return newRecordWriter(writer, csvOptions)
+}
+
+// marshalChunkSize is the number of rows marshalled between context checks.
+const marshalChunkSize = 100
+
+func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	return f.FormatContext(context.Background(), writer, options, data...)
+}
+
+func (f *formatter) FormatContext(ctx context.Context, writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	csvOptions, err := getOptions(options, f.format)
+	if err != nil {
+		return err
 	}
 
+	// Set up writer with options, writes stop as soon as the context is done
+	out := newCSVWriter(yaff.NewContextWriter(ctx, writer), csvOptions)
+
 	// marahal each output
 	for _, d := range data {
-		err := marshaller(d, out)
+		if !csvOptions.CSVTags {
+			if err := writeTabulated(ctx, d, out, csvOptions); err != nil {
+				return err
+			}
+			continue
+		}
+
+		d, err := sortData(d, csvOptions.SortBy)
 		if err != nil {
 			return err
 		}
+
+		d, _ = csvOptions.page().Apply(d)
+
+		if err := marshalContext(ctx, d, out, csvOptions.IncludeHeader); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// marshalContext marshals slices in chunks, checking the context between each chunk.
+func marshalContext(ctx context.Context, d interface{}, out gocsv.CSVWriter, includeHeader bool) error {
+	// Set header mode
+	marshaller := gocsv.MarshalCSVWithoutHeaders
+	if includeHeader {
+		marshaller = gocsv.MarshalCSV
+	}
+
+	value := reflect.ValueOf(d)
+	if value.Kind() != reflect.Slice || value.Len() <= marshalChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return marshaller(d, out)
 	}
+
+	n := value.Len()
+	for i := 0; i < n; i += marshalChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := i + marshalChunkSize
+		if end > n {
+			end = n
+		}
+
+		if err := marshaller(value.Slice(i, end).Interface(), out); err != nil {
+			return err
+		}
+
+		// Only the first chunk has a header
+		marshaller = gocsv.MarshalCSVWithoutHeaders
+	}
+
 	return nil
 }
 
+// sortData returns a sorted copy of a slice or array, other data is returned as is.
+func sortData(d interface{}, keys []rowset.SortKey) (interface{}, error) {
+	value := reflect.ValueOf(d)
+	if value.Kind() == reflect.Ptr {
+		value = reflect.Indirect(value)
+	}
+
+	if len(keys) == 0 || (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) {
+		return d, nil
+	}
+
+	order, err := rowset.SortIndices(value, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), len(order), len(order))
+	for i, index := range order {
+		sorted.Index(i).Set(value.Index(index))
+	}
+
+	return sorted.Interface(), nil
+}
+
 func init() {
 	// Register this formatter
 	yaff.Formatters().Register(CSV, NewFormatter)
+	yaff.Formatters().Register(TSV, NewTSVFormatter)
+	yaff.Formatters().RegisterDecoder(CSV, NewDecoder)
+	yaff.Formatters().RegisterDecoder(TSV, NewTSVDecoder)
 }
@@ -18,10 +18,14 @@ package csvformatter
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/nehemming/testsupport"
 	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/rowset"
 )
 
 func TestCSV(t *testing.T) {
@@ -103,3 +107,167 @@ Hello,99,2.7
 
 	testsupport.CompareStrings(t, expected, got)
 }
+
+func TestFormatContextChunks(t *testing.T) {
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	rows := make([]testData, marshalChunkSize+1)
+
+	var buf bytes.Buffer
+
+	err = f.(yaff.ContextFormatter).FormatContext(context.Background(), &buf, nil, rows)
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != len(rows)+1 {
+		t.Errorf("unexpected line count %d", lines)
+	}
+}
+
+func TestFormatContextCancelled(t *testing.T) {
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+
+	err = f.(yaff.ContextFormatter).FormatContext(ctx, &buf, nil, make([]testData, marshalChunkSize*3))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestNewFormatterSortBy(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.SortBy = rowset.ParseSortKeys("-I")
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, options, []testData{
+		{S: "Hello", I: 10, F: 3.14},
+		{S: "Train", I: 110, F: 3.99},
+		{S: "Bye", I: 9, F: 1},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `S,I,F
+Train,110,3.99
+Hello,10,3.14
+Bye,9,1
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+type nestedData struct {
+	Name  string `csv:"name"`
+	Inner *testData
+}
+
+func TestNewFormatterColumns(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("Inner.I as Count,name")
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, options, []nestedData{
+		{Name: "one", Inner: &testData{I: 1}},
+		{Name: "two"},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `Count,Name
+1,one
+,two
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewFormatterColumnsUnknown(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("Missing")
+
+	if err := fmt.Format(&bytes.Buffer{}, options, []testData{{S: "Hello"}}); err == nil {
+		t.Error("No unknown column error")
+	}
+}
+
+type stateData struct {
+	Name  string
+	State *state
+}
+
+type state int
+
+func (s *state) String() string {
+	return []string{"stopped", "running"}[*s]
+}
+
+func TestNewFormatterColumnsStringer(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	running := state(1)
+
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("Name,State")
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, options, []stateData{{Name: "one", State: &running}, {Name: "two"}})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Name,State\none,running\ntwo,\n", buf.String())
+}
+
+func TestNewFormatterPage(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	options := NewOptions()
+	options.SortBy = []rowset.SortKey{{Column: "I", Descending: true}}
+	options.Offset = 1
+	options.Limit = 1
+
+	var buf bytes.Buffer
+
+	err := fmt.Format(&buf, options, []testData{{S: "a", I: 1}, {S: "b", I: 2}, {S: "c", I: 3}})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "S,I,F\nb,2,0\n", buf.String())
+}
@@ -0,0 +1,192 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/gocarina/gocsv"
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+	"github.com/nehemming/yaff/textformatter"
+)
+
+// NewDecoder return a new decoder reading csv.
+func NewDecoder() (yaff.Decoder, error) {
+	return &decoder{format: CSV}, nil
+}
+
+// NewTSVDecoder return a new decoder reading tab separated values.
+func NewTSVDecoder() (yaff.Decoder, error) {
+	return &decoder{format: TSV}, nil
+}
+
+type decoder struct {
+	format yaff.Format
+	header []string
+}
+
+// Decode reads records using the options they were written with, naming columns by the header the
+// formatter outputs.  Columns output with tag formats, such as unit or percent, cannot be decoded.
+// When CSVTags is set records are unmarshalled with gocsv, which requires the target to be a slice.
+func (d *decoder) Decode(reader io.Reader, options yaff.FormatOptions, target interface{}) error {
+	csvOptions, err := getOptions(options, d.format)
+	if err != nil {
+		return err
+	}
+
+	t, err := rowset.NewTarget(target)
+	if err != nil {
+		return err
+	}
+
+	in := newRecordReader(reader, csvOptions)
+	d.header = nil
+
+	if csvOptions.CSVTags {
+		return unmarshalTarget(in, t, csvOptions.IncludeHeader)
+	}
+
+	var det *textformatter.Detabulator
+	if !csvOptions.IncludeHeader {
+		if det, err = textformatter.NewDetabulator(csvOptions.textOptions(), t.Type(), nil); err != nil {
+			return err
+		}
+		d.header = det.Header()
+	}
+
+	for {
+		record, err := in.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := in.FieldPos(0)
+
+		if det == nil {
+			if det, err = textformatter.NewDetabulator(csvOptions.textOptions(), t.Type(), record); err != nil {
+				return &yaff.DecodeError{Line: line, Err: err}
+			}
+			d.header = det.Header()
+			continue
+		}
+
+		value := t.New()
+		if i, err := det.Decode(record, value); err != nil {
+			if i < 0 {
+				return &yaff.DecodeError{Line: line, Err: err}
+			}
+			line, column := in.FieldPos(i)
+			return &yaff.DecodeError{Line: line, Column: column, Err: err}
+		}
+		t.Add(value)
+	}
+}
+
+// Header returns the column names of the records last decoded, nil if they were unmarshalled with gocsv.
+func (d *decoder) Header() []string {
+	return d.header
+}
+
+// unmarshalTarget unmarshals records named by their csv tags with gocsv.
+func unmarshalTarget(in *recordReader, t *rowset.Target, includeHeader bool) error {
+	records := reflect.New(reflect.SliceOf(t.Type()))
+
+	unmarshal := gocsv.UnmarshalCSVWithoutHeaders
+	if includeHeader {
+		unmarshal = gocsv.UnmarshalCSV
+	}
+
+	if err := unmarshal(in, records.Interface()); err != nil {
+		return err
+	}
+
+	for i := 0; i < records.Elem().Len(); i++ {
+		t.Add(records.Elem().Index(i))
+	}
+
+	return nil
+}
+
+// recordReader reads records written by a recordWriter with encoding/csv, it implements gocsv.CSVReader.
+// A leading byte order mark is skipped and syntax errors are returned as decode errors.
+type recordReader struct {
+	*csv.Reader
+}
+
+// newRecordReader returns a reader using the dialect of the options, which must have been validated.
+func newRecordReader(reader io.Reader, csvOptions Options) *recordReader {
+	br := bufio.NewReader(reader)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	rr := &recordReader{Reader: csv.NewReader(br)}
+
+	if csvOptions.ColumnSeparator != "" {
+		rr.Comma, _ = utf8.DecodeRuneInString(csvOptions.ColumnSeparator)
+	}
+
+	// The number of fields is checked against the header when the record is decoded
+	rr.FieldsPerRecord = -1
+
+	return rr
+}
+
+// Read reads the next record, returning io.EOF when there are no more.
+func (rr *recordReader) Read() ([]string, error) {
+	record, err := rr.Reader.Read()
+
+	var parseError *csv.ParseError
+	if errors.As(err, &parseError) {
+		switch parseError.Err {
+		case csv.ErrBareQuote:
+			err = &yaff.DecodeError{Line: parseError.Line, Column: parseError.Column, Err: lpax.Errorf(langpack.ErrorBareQuote)}
+		case csv.ErrQuote:
+			err = &yaff.DecodeError{Line: parseError.Line, Column: parseError.Column, Err: lpax.Errorf(langpack.ErrorQuote)}
+		}
+	}
+
+	return record, err
+}
+
+// ReadAll reads the remaining records.
+func (rr *recordReader) ReadAll() ([][]string, error) {
+	var records [][]string
+
+	for {
+		record, err := rr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+}
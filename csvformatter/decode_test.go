@@ -0,0 +1,216 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+)
+
+func decodeCSV(t *testing.T, options Options, input string, target interface{}) error {
+	t.Helper()
+
+	dec, err := yaff.Formatters().GetDecoder(CSV)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	return dec.Decode(strings.NewReader(input), options, target)
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	options := NewOptions()
+	options.QuoteAll = true
+	options.CRLF = true
+	options.BOM = true
+
+	input := formatCSV(t, options, serviceRows())
+
+	var got []serviceData
+	if err := decodeCSV(t, options, input, &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	exp := serviceRows()
+	exp[0].secret = ""
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v expected %v", got, exp)
+	}
+}
+
+func TestDecodeNullText(t *testing.T) {
+	options := NewOptions()
+	options.NullText = "NULL"
+
+	input := formatCSV(t, options, dialectRows())
+
+	var got []*dialectData
+	if err := decodeCSV(t, options, input, &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(got) != 2 || *got[0].Count != 3 || got[1].Count != nil || got[1].Note != "line\nbreak" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeWithoutHeader(t *testing.T) {
+	options := NewOptions()
+	options.IncludeHeader = false
+
+	var got []serviceData
+	if err := decodeCSV(t, options, "api,2,512,true\n", &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(got) != 1 || got[0].Name != "api" || got[0].Limits.Memory != 512 || !got[0].Healthy {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeMaps(t *testing.T) {
+	var got []map[string]interface{}
+	if err := decodeCSV(t, NewOptions(), "Name,Size\na,1\n\nb,2\n", &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	exp := []map[string]interface{}{{"Name": "a", "Size": "1"}, {"Name": "b", "Size": "2"}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v expected %v", got, exp)
+	}
+}
+
+func TestDecodeEmptySingleColumn(t *testing.T) {
+	type note struct {
+		Note string
+	}
+
+	rows := []note{{"a"}, {""}, {"b"}}
+	input := formatCSV(t, NewOptions(), rows)
+
+	var got []note
+	if err := decodeCSV(t, NewOptions(), input, &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("got %v expected %v", got, rows)
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	dec, err := yaff.Formatters().GetDecoder(CSV)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var got []map[string]interface{}
+	if err := dec.Decode(strings.NewReader("Zone, Name\neu,web\n"), nil, &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	hd, ok := dec.(yaff.HeaderDecoder)
+	if !ok || !reflect.DeepEqual(hd.Header(), []string{"Zone", "Name"}) {
+		t.Errorf("Unexpected header %v", dec)
+	}
+}
+
+func TestDecodeSingle(t *testing.T) {
+	var got serviceData
+	if err := decodeCSV(t, NewOptions(), "Service,Mem\ndb,64\n", &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if got.Name != "db" || got.Limits.Memory != 64 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeTSV(t *testing.T) {
+	dec, err := yaff.Formatters().GetDecoder(TSV)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var got []dialectData
+	if err := dec.Decode(strings.NewReader("Name\tNote\na\tone, two\n"), nil, &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(got) != 1 || got[0].Note != "one, two" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeCSVTags(t *testing.T) {
+	options := NewOptions()
+	options.CSVTags = true
+
+	var buf bytes.Buffer
+	fmt, _ := NewFormatter()
+	if err := fmt.Format(&buf, options, dialectRows()); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var got []dialectData
+	if err := decodeCSV(t, options, buf.String(), &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(got) != 2 || *got[0].Count != 3 || got[1].Note != "line\nbreak" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	var got []serviceData
+	err := decodeCSV(t, NewOptions(), "Service,CPU\n\"a\nb\",x\n", &got)
+
+	var decodeError *yaff.DecodeError
+	if !errors.As(err, &decodeError) || decodeError.Line != 3 || decodeError.Column != 4 {
+		t.Errorf("err %v", err)
+	}
+
+	if decodeError.Err.Error() != lpax.Sprintf(langpack.ErrorCellText, "x", reflect.TypeOf(0)) {
+		t.Errorf("err %v", decodeError.Err)
+	}
+}
+
+func TestDecodeSyntaxErrors(t *testing.T) {
+	for input, exp := range map[string]*yaff.DecodeError{
+		"Name\na\"b\n":       {Line: 2, Column: 2, Err: lpax.Errorf(langpack.ErrorBareQuote)},
+		"Name\n\"ab\"c\n":    {Line: 2, Column: 4, Err: lpax.Errorf(langpack.ErrorQuote)},
+		"Name\n\"ab\n":       {Line: 2, Column: 5, Err: lpax.Errorf(langpack.ErrorQuote)},
+		"Name,Note\na,b,c\n": {Line: 2, Err: lpax.Errorf(langpack.ErrorFieldCount, 3, 2)},
+		"Name,Size\na,1\n":   {Line: 1, Err: lpax.Errorf(langpack.ErrorUnknownColumns, "Size", reflect.TypeOf(dialectData{}))},
+	} {
+		var got []dialectData
+		err := decodeCSV(t, NewOptions(), input, &got)
+
+		if err == nil || err.Error() != exp.Error() {
+			t.Errorf("input %q err %v expected %v", input, err, exp)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// utf8BOM is the byte order mark written at the start of output when Options.BOM is set.
+const utf8BOM = "\xef\xbb\xbf"
+
+// recordWriter writes records using the dialect options, it implements gocsv.CSVWriter.
+// Fields are quoted using the same rules as encoding/csv unless every field is quoted.
+type recordWriter struct {
+	w        *bufio.Writer
+	comma    rune
+	quoteAll bool
+	lineEnd  string
+	bom      bool
+	err      error
+}
+
+// newRecordWriter returns a writer using the dialect of the options, which must have been validated.
+func newRecordWriter(writer io.Writer, csvOptions Options) *recordWriter {
+	rw := &recordWriter{
+		w:        bufio.NewWriter(writer),
+		comma:    ',',
+		quoteAll: csvOptions.QuoteAll,
+		lineEnd:  "\n",
+		bom:      csvOptions.BOM,
+	}
+
+	if csvOptions.ColumnSeparator != "" {
+		rw.comma, _ = utf8.DecodeRuneInString(csvOptions.ColumnSeparator)
+	}
+
+	if csvOptions.CRLF {
+		rw.lineEnd = "\r\n"
+	}
+
+	return rw
+}
+
+// validSeparator checks the column separator is a single character that can separate fields.
+func validSeparator(separator string) error {
+	if separator == "" {
+		return nil
+	}
+
+	r, size := utf8.DecodeRuneInString(separator)
+	if size != len(separator) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return lpax.Errorf(langpack.ErrorInvalidSeparator, separator)
+	}
+
+	return nil
+}
+
+func (rw *recordWriter) Write(record []string) error {
+	if rw.err != nil {
+		return rw.err
+	}
+
+	// The byte order mark precedes the first record
+	if rw.bom {
+		rw.bom = false
+		rw.writeString(utf8BOM)
+	}
+
+	for i, field := range record {
+		if i > 0 {
+			rw.writeString(string(rw.comma))
+		}
+
+		// A lone empty field is quoted so the record is not read as a blank line
+		if !rw.needsQuotes(field) && (field != "" || len(record) > 1) {
+			rw.writeString(field)
+			continue
+		}
+
+		field = strings.ReplaceAll(field, `"`, `""`)
+		if rw.lineEnd == "\r\n" {
+			field = strings.ReplaceAll(strings.ReplaceAll(field, "\r\n", "\n"), "\n", "\r\n")
+		}
+
+		rw.writeString(`"` + field + `"`)
+	}
+
+	rw.writeString(rw.lineEnd)
+
+	return rw.err
+}
+
+func (rw *recordWriter) writeString(s string) {
+	if rw.err == nil {
+		_, rw.err = rw.w.WriteString(s)
+	}
+}
+
+// needsQuotes returns true if the field is quoted, following the rules of encoding/csv.
+func (rw *recordWriter) needsQuotes(field string) bool {
+	if rw.quoteAll {
+		return true
+	}
+
+	if field == "" {
+		return false
+	}
+
+	if field == `\.` || strings.ContainsRune(field, rw.comma) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
+
+func (rw *recordWriter) Flush() {
+	if rw.err == nil {
+		rw.err = rw.w.Flush()
+	}
+}
+
+func (rw *recordWriter) Error() error {
+	return rw.err
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+type dialectData struct {
+	Name  string
+	Note  string
+	Count *int
+}
+
+func dialectRows() []dialectData {
+	three := 3
+	return []dialectData{
+		{Name: "a", Note: "one, two", Count: &three},
+		{Name: "b", Note: "line\nbreak"},
+	}
+}
+
+func TestDialectDefault(t *testing.T) {
+	got := formatCSV(t, NewOptions(), dialectRows())
+
+	testsupport.CompareStrings(t, "Name,Note,Count\na,\"one, two\",3\nb,\"line\nbreak\",\n", got)
+}
+
+func TestDialectQuoteAll(t *testing.T) {
+	options := NewOptions()
+	options.QuoteAll = true
+
+	got := formatCSV(t, options, dialectRows())
+
+	testsupport.CompareStrings(t, "\"Name\",\"Note\",\"Count\"\n\"a\",\"one, two\",\"3\"\n\"b\",\"line\nbreak\",\"\"\n", got)
+}
+
+func TestDialectCRLF(t *testing.T) {
+	options := NewOptions()
+	options.CRLF = true
+
+	got := formatCSV(t, options, dialectRows())
+
+	testsupport.CompareStrings(t, "Name,Note,Count\r\na,\"one, two\",3\r\nb,\"line\r\nbreak\",\r\n", got)
+}
+
+func TestDialectBOM(t *testing.T) {
+	options := NewOptions()
+	options.BOM = true
+
+	got := formatCSV(t, options, []dialectData{{Name: "a"}})
+
+	testsupport.CompareStrings(t, "\xef\xbb\xbfName,Note,Count\na,,\n", got)
+}
+
+func TestDialectNullText(t *testing.T) {
+	options := NewOptions()
+	options.NullText = "NULL"
+
+	got := formatCSV(t, options, dialectRows())
+
+	testsupport.CompareStrings(t, "Name,Note,Count\na,\"one, two\",3\nb,\"line\nbreak\",NULL\n", got)
+}
+
+func TestDialectCSVTagsAllColumns(t *testing.T) {
+	options := NewOptions()
+	options.CSVTags = true
+	options.NullText = "NULL"
+	options.Columns = []rowset.ColumnSpec{{Field: "Name"}, {Field: "Count"}}
+
+	got := formatCSV(t, options, dialectRows())
+
+	testsupport.CompareStrings(t, "Name,Note,Count\na,\"one, two\",3\nb,\"line\nbreak\",\n", got)
+}
+
+func TestDialectTSV(t *testing.T) {
+	fmt, err := yaff.Formatters().GetFormatter(TSV)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, nil, dialectRows()); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	// CompareStrings expands tabs differently in each string so they are compared exactly
+	if got, exp := buf.String(), "Name\tNote\tCount\na\tone, two\t3\nb\t\"line\nbreak\"\t\n"; got != exp {
+		t.Errorf("got %q expected %q", got, exp)
+	}
+}
+
+func TestDialectInvalidSeparator(t *testing.T) {
+	for _, separator := range []string{"||", "\"", "\n"} {
+		fmt, _ := NewFormatter()
+
+		options := NewOptions()
+		options.ColumnSeparator = separator
+
+		var buf bytes.Buffer
+		err := fmt.Format(&buf, options, dialectRows())
+		if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorInvalidSeparator, separator) {
+			t.Error("err:", err)
+		}
+	}
+}
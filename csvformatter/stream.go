@@ -0,0 +1,158 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/gocarina/gocsv"
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+	"github.com/nehemming/yaff/textformatter"
+)
+
+type stream struct {
+	out gocsv.CSVWriter
+	// tabulator reflects rows unless csv tags are used.
+	tabulator     *textformatter.Tabulator
+	headerWritten bool
+	includeHeader bool
+	page          rowset.Page
+	rows          int
+	// seen is the number of rows received, including those outside the page.
+	seen int
+	// rowType is the type of the first row, pointers removed, every row must have it.
+	rowType reflect.Type
+	closed  bool
+}
+
+func (f *formatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	csvOptions, err := getOptions(options, f.format)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &stream{
+		out:           newCSVWriter(writer, csvOptions),
+		includeHeader: csvOptions.IncludeHeader,
+		page:          csvOptions.page(),
+	}
+
+	if !csvOptions.CSVTags {
+		// Rows outside the page are skipped by the stream
+		textOptions := csvOptions.textOptions()
+		textOptions.Offset, textOptions.Limit = 0, 0
+
+		s.tabulator = textformatter.NewTabulator(textOptions)
+	}
+
+	return s, nil
+}
+
+func (s *stream) Write(row interface{}) error {
+	if s.closed {
+		return lpax.Errorf(langpack.ErrorStreamClosed)
+	}
+
+	// Lists have no columns to write
+	if k := reflect.Indirect(reflect.ValueOf(row)).Kind(); k == reflect.Slice || k == reflect.Array {
+		return lpax.Errorf(langpack.ErrorStreamRow, row)
+	}
+
+	// The columns are those of the first row's type
+	if t := reflect.TypeOf(row); t != nil {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if s.rowType == nil {
+			s.rowType = t
+		} else if t != s.rowType {
+			return lpax.Errorf(langpack.ErrorStreamRowType, t, s.rowType)
+		}
+	}
+
+	s.seen++
+	if !s.page.Includes(s.seen - 1) {
+		return nil
+	}
+
+	if s.tabulator != nil {
+		return s.writeTabulated(row)
+	}
+
+	// Marshal the row as a single item slice, only the first row includes the header
+	value := reflect.ValueOf(row)
+	if !value.IsValid() {
+		return nil
+	}
+
+	slice := reflect.Append(reflect.MakeSlice(reflect.SliceOf(value.Type()), 0, 1), value)
+
+	marshaller := gocsv.MarshalCSVWithoutHeaders
+	if s.rows == 0 && s.includeHeader {
+		marshaller = gocsv.MarshalCSV
+	}
+
+	if err := marshaller(slice.Interface(), s.out); err != nil {
+		return err
+	}
+
+	s.rows++
+
+	return nil
+}
+
+func (s *stream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.tabulator != nil {
+		if footer := s.tabulator.Footer(); footer != nil {
+			if err := s.out.Write(footer); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.out.Flush()
+	return s.out.Error()
+}
+
+// writeTabulated writes a row using the column plan of the text formatter, preceded by the header for the first row.
+func (s *stream) writeTabulated(row interface{}) error {
+	record, err := s.tabulator.Row(row)
+	if err != nil || record == nil {
+		return err
+	}
+
+	if !s.headerWritten && s.includeHeader {
+		if err := s.out.Write(s.tabulator.Header()); err != nil {
+			return err
+		}
+	}
+	s.headerWritten = true
+
+	s.rows++
+
+	return s.out.Write(record)
+}
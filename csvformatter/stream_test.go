@@ -0,0 +1,140 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+)
+
+func TestStream(t *testing.T) {
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, nil)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	for _, row := range []interface{}{
+		testData{S: "Hello", I: 10, F: 3.14},
+		testData{S: "Train", I: 11, F: 3.99},
+	} {
+		if err := stream.Write(row); err != nil {
+			t.Errorf("Write Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	expected := `S,I,F
+Hello,10,3.14
+Train,11,3.99
+`
+
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestStreamWithoutHeader(t *testing.T) {
+	f, _ := NewFormatter()
+
+	options := NewOptions()
+	options.IncludeHeader = false
+	options.ColumnSeparator = "|"
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, options)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	_ = stream.Write(&testData{S: "Hello", I: 10, F: 3.14})
+	_ = stream.Close()
+
+	testsupport.CompareStrings(t, "Hello|10|3.14\n", buf.String())
+}
+
+func TestStreamListRow(t *testing.T) {
+	f, _ := NewFormatter()
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, nil)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	rows := []testData{{S: "Hello", I: 10, F: 3.14}}
+
+	if err := stream.Write(rows); err == nil || err.Error() != lpax.Sprintf(langpack.ErrorStreamRow, rows) {
+		t.Errorf("err %v", err)
+	}
+
+	if err := stream.Write(&rows[0]); err != nil {
+		t.Errorf("Write Error %v", err)
+	}
+
+	_ = stream.Close()
+
+	testsupport.CompareStrings(t, "S,I,F\nHello,10,3.14\n", buf.String())
+}
+
+func TestStreamRowType(t *testing.T) {
+	f, _ := NewFormatter()
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, nil)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	if err := stream.Write(testData{S: "Hello", I: 10, F: 3.14}); err != nil {
+		t.Errorf("Write Error %v", err)
+	}
+
+	other := serviceData{Name: "api"}
+	exp := lpax.Sprintf(langpack.ErrorStreamRowType, reflect.TypeOf(other), reflect.TypeOf(testData{}))
+	if err := stream.Write(other); err == nil || err.Error() != exp {
+		t.Errorf("err %v", err)
+	}
+
+	if err := stream.Write(&testData{S: "Train", I: 11, F: 3.99}); err != nil {
+		t.Errorf("Write Error %v", err)
+	}
+
+	_ = stream.Close()
+
+	testsupport.CompareStrings(t, "S,I,F\nHello,10,3.14\nTrain,11,3.99\n", buf.String())
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"context"
+
+	"github.com/gocarina/gocsv"
+	"github.com/nehemming/yaff/textformatter"
+)
+
+// textOptions returns the text formatter options used to tabulate rows.
+func (options Options) textOptions() textformatter.Options {
+	textOptions := textformatter.NewOptions()
+	textOptions.Style = textformatter.Plain
+	textOptions.ColumnSet = options.ColumnSet
+	textOptions.ExcludeSet = options.ExcludeSet
+	textOptions.Columns = options.Columns
+	textOptions.SortBy = options.SortBy
+	textOptions.Aggregates = options.Aggregates
+	textOptions.Offset = options.Offset
+	textOptions.Limit = options.Limit
+	textOptions.NilText = options.NullText
+
+	return textOptions
+}
+
+// writeTabulated writes the data using the column plan of the text formatter, followed by any totals row.
+func writeTabulated(ctx context.Context, d interface{}, out gocsv.CSVWriter, csvOptions Options) error {
+	tab := textformatter.NewTabulator(csvOptions.textOptions())
+
+	rows, err := tab.Tabulate(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	if header := tab.Header(); csvOptions.IncludeHeader && len(header) > 0 {
+		if err := out.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for i, row := range rows {
+		if i%marshalChunkSize == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	if footer := tab.Footer(); footer != nil {
+		return out.Write(footer)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/textformatter"
+)
+
+type serviceLimits struct {
+	CPU    int
+	Memory int `tabular:"Mem"`
+}
+
+type serviceData struct {
+	Name    string `csv:"service" tabular:"Service"`
+	Limits  serviceLimits
+	Healthy bool `tabular:",trueonly"`
+	secret  string
+}
+
+func serviceRows() []serviceData {
+	return []serviceData{
+		{Name: "api", Limits: serviceLimits{CPU: 2, Memory: 512}, Healthy: true, secret: "x"},
+		{Name: "db", Limits: serviceLimits{CPU: 4, Memory: 2048}},
+	}
+}
+
+func formatCSV(t *testing.T, options Options, data interface{}) string {
+	t.Helper()
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestTabularTags(t *testing.T) {
+	got := formatCSV(t, NewOptions(), serviceRows())
+
+	testsupport.CompareStrings(t, "Service,CPU,Mem,Healthy\napi,2,512,true\ndb,4,2048,\n", got)
+}
+
+func TestTabularMatchesText(t *testing.T) {
+	options := NewOptions()
+	options.ExcludeSet = map[string]bool{"CPU": true}
+
+	got := formatCSV(t, options, serviceRows())
+
+	textOptions := textformatter.NewOptions()
+	textOptions.Style = textformatter.Plain
+	textOptions.ColumnSeparator = ","
+	textOptions.ExcludeSet = map[string]bool{"CPU": true}
+
+	f, _ := textformatter.NewFormatter()
+
+	var text bytes.Buffer
+	if err := f.Format(&text, textOptions, serviceRows()); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, text.String(), got)
+}
+
+func TestTabularColumnSetStruct(t *testing.T) {
+	options := NewOptions()
+	options.ColumnSet = map[string]bool{"service": true, "limits": true, "mem": true}
+
+	// A single struct is a single row
+	got := formatCSV(t, options, &serviceRows()[0])
+
+	testsupport.CompareStrings(t, "Service,Mem\napi,512\n", got)
+}
+
+func TestTabularEmpty(t *testing.T) {
+	got := formatCSV(t, NewOptions(), []*serviceData{})
+
+	testsupport.CompareStrings(t, "Service,CPU,Mem,Healthy\n", got)
+}
+
+func TestTabularKeepsEscapes(t *testing.T) {
+	rows := []serviceData{{Name: "\x1b[31mapi\x1b[0m"}}
+
+	got := formatCSV(t, NewOptions(), rows)
+
+	testsupport.CompareStrings(t, "Service,CPU,Mem,Healthy\n\x1b[31mapi\x1b[0m,0,0,\n", got)
+
+	var decoded []serviceData
+	if err := decodeCSV(t, NewOptions(), got, &decoded); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(decoded) != 1 || decoded[0].Name != rows[0].Name {
+		t.Errorf("got %v", decoded)
+	}
+}
+
+func TestCSVTags(t *testing.T) {
+	options := NewOptions()
+	options.CSVTags = true
+
+	got := formatCSV(t, options, []serviceData{{Name: "api", Healthy: true}})
+
+	if !strings.HasPrefix(got, "service,") {
+		t.Errorf("unexpected csv tag output %q", got)
+	}
+}
+
+func TestTabularStream(t *testing.T) {
+	f, _ := NewFormatter()
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, NewOptions())
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	for _, row := range serviceRows() {
+		if err := stream.Write(row); err != nil {
+			t.Errorf("Write Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Service,CPU,Mem,Healthy\napi,2,512,true\ndb,4,2048,\n", buf.String())
+}
@@ -0,0 +1,133 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csvformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+func totalsData() []testData {
+	return []testData{{S: "Hello", I: 10, F: 3.5}, {S: "Train", I: 11, F: 1.5}}
+}
+
+func formatTotals(t *testing.T, options Options, data interface{}) string {
+	t.Helper()
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestTotals(t *testing.T) {
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"i": rowset.Sum, "F": rowset.Avg}
+
+	got := formatTotals(t, options, totalsData())
+
+	testsupport.CompareStrings(t, "S,I,F\nHello,10,3.5\nTrain,11,1.5\nTotal,21,2.5\n", got)
+}
+
+func TestTotalsWithoutHeader(t *testing.T) {
+	options := NewOptions()
+	options.IncludeHeader = false
+	options.Aggregates = map[string]rowset.Aggregate{"s": rowset.Count, "f": rowset.Max}
+
+	got := formatTotals(t, options, totalsData())
+
+	testsupport.CompareStrings(t, "Hello,10,3.5\nTrain,11,1.5\n2,Total,3.5\n", got)
+}
+
+func TestTotalsColumns(t *testing.T) {
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("S as Name,I as Count")
+	options.Aggregates = map[string]rowset.Aggregate{"count": rowset.Min}
+
+	got := formatTotals(t, options, totalsData())
+
+	testsupport.CompareStrings(t, "Name,Count\nHello,10\nTrain,11\nMin,10\n", got)
+}
+
+func TestTotalsEmpty(t *testing.T) {
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"i": rowset.Sum}
+
+	got := formatTotals(t, options, []testData{})
+
+	testsupport.CompareStrings(t, "S,I,F\n", got)
+}
+
+func TestTotalsNotNumeric(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"s": rowset.Sum}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, options, totalsData())
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorAggregateNotNumeric, "S", rowset.Sum) {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestTotalsStream(t *testing.T) {
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"i": rowset.Sum}
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, options)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	for _, row := range totalsData() {
+		if err := stream.Write(row); err != nil {
+			t.Errorf("Write Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "S,I,F\nHello,10,3.5\nTrain,11,1.5\nSum,21,\n", buf.String())
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaff
+
+import (
+	"io"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// Decoder reads data written in a format back into Go values.
+type Decoder interface {
+
+	// Decode reads the records of the input from the reader into the target using the format options.
+	// The target must be a non nil pointer, records are appended to a slice and a single record sets any other type.
+	Decode(reader io.Reader, options FormatOptions, target interface{}) error
+}
+
+// HeaderDecoder is a Decoder of a tabular format that reports the header naming its columns.
+type HeaderDecoder interface {
+	Decoder
+
+	// Header returns the column names of the input read by the last call to Decode, in input order.
+	Header() []string
+}
+
+// NewDecoder function type to create a new decoder.
+// Each decoder type registered will provide an implementation to create an instance of an associated decoder.
+type NewDecoder func() (Decoder, error)
+
+// DecodeError is an error decoding the input at a position.
+// Lines and columns count from 1, Column is 0 when only the line is known.
+type DecodeError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Column == 0 {
+		return lpax.Sprintf(langpack.ErrorDecodeLine, e.Line, e.Err)
+	}
+	return lpax.Sprintf(langpack.ErrorDecodePosition, e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
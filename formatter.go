@@ -18,6 +18,7 @@ limitations under the License.
 package yaff
 
 import (
+	"context"
 	"io"
 )
 
@@ -31,6 +32,33 @@ type Formatter interface {
 	Format(writer io.Writer, options FormatOptions, data ...interface{}) error
 }
 
+// ContextFormatter is a Formatter whose formatting can be cancelled through a context.
+type ContextFormatter interface {
+	Formatter
+
+	// FormatContext formats the supplied input data using the format options with output to the writer.
+	// If the context is cancelled formatting stops promptly, returning ctx.Err().
+	FormatContext(ctx context.Context, writer io.Writer, options FormatOptions, data ...interface{}) error
+}
+
+// StreamFormatter supports writing formatted data a row at a time.
+// Streams allow large result sets to be output without holding every row in memory.
+type StreamFormatter interface {
+
+	// NewStream opens a stream writing rows to the writer using the format options.
+	NewStream(writer io.Writer, options FormatOptions) (Stream, error)
+}
+
+// Stream accepts rows incrementally, Close must be called to complete the output.
+type Stream interface {
+
+	// Write formats a single row to the stream.
+	Write(row interface{}) error
+
+	// Close completes the output, flushing any buffered rows.
+	Close() error
+}
+
 // FormatOptions options controlling formatting, every formatter can implement its own options.
 type FormatOptions interface{}
 
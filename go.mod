@@ -3,13 +3,13 @@ module github.com/nehemming/yaff
 go 1.15
 
 require (
-	github.com/eidolon/wordwrap v0.0.0-20161011182207-e0f54129b8bb
 	github.com/gocarina/gocsv v0.0.0-20201103164230-b291445e0dd2
 	github.com/nehemming/fsio v0.5.0
 	github.com/nehemming/lpax v0.0.1
 	github.com/nehemming/testsupport v0.0.0-20201206084157-e42fc749801f
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.8.1
+	golang.org/x/term v0.0.0-20210503060354-a79de5458b56
 	golang.org/x/text v0.3.6
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
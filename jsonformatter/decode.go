@@ -0,0 +1,273 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/rowset"
+)
+
+// NewDecoder return a new decoder reading JSON documents.
+func NewDecoder() (yaff.Decoder, error) {
+	return &decoder{flatten: true}, nil
+}
+
+// NewLinesDecoder return a new decoder reading newline delimited JSON.
+func NewLinesDecoder() (yaff.Decoder, error) {
+	return &decoder{}, nil
+}
+
+// decoder reads a sequence of JSON documents, flattening arrays into records when decoding JSON.
+type decoder struct {
+	flatten bool
+}
+
+// Decode reads the JSON documents of the input.  JSON arrays are added to a slice target a record per item,
+// unless each record is itself a list, while each JSON line is a single record.
+// If the Envelope option is set the records are taken from the items of each document.
+func (d *decoder) Decode(reader io.Reader, options yaff.FormatOptions, target interface{}) error {
+	format := JSON
+	if !d.flatten {
+		format = JSONLines
+	}
+
+	jsonOptions, err := getOptions(options, format)
+	if err != nil {
+		return err
+	}
+
+	t, err := rowset.NewTarget(target)
+	if err != nil {
+		return err
+	}
+
+	input, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+
+	for {
+		var doc json.RawMessage
+
+		start := skipSpace(input, int(dec.InputOffset()))
+		if err := dec.Decode(&doc); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return positionError(input, 0, err)
+		}
+
+		if jsonOptions.Envelope && d.flatten {
+			var envelope struct {
+				Items json.RawMessage `json:"items"`
+			}
+
+			if err := json.Unmarshal(doc, &envelope); err != nil {
+				return positionError(input, start, err)
+			}
+
+			start += bytes.Index(doc, envelope.Items)
+			doc = envelope.Items
+		}
+
+		if err := d.decodeDoc(doc, t); err != nil {
+			return positionError(input, start, err)
+		}
+	}
+}
+
+// decodeDoc decodes a document into the target, flattening arrays if the records are not lists.
+func (d *decoder) decodeDoc(doc json.RawMessage, t *rowset.Target) error {
+	if d.flatten && len(doc) > 0 && doc[0] == '[' && !isListType(t.Type()) {
+		records := reflect.New(reflect.SliceOf(t.Type()))
+		if err := unmarshal(doc, records); err != nil {
+			return err
+		}
+
+		for i := 0; i < records.Elem().Len(); i++ {
+			t.Add(records.Elem().Index(i))
+		}
+
+		return nil
+	}
+
+	record := t.New()
+	if err := unmarshal(doc, record.Addr()); err != nil {
+		return err
+	}
+
+	t.Add(record)
+
+	return nil
+}
+
+// unmarshal decodes the document into the value ptr points to.  Numbers held by interface values are
+// decoded as int64 if they are integers, rather than as float64, so large integers keep their digits.
+func unmarshal(doc json.RawMessage, ptr reflect.Value) error {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	if err := dec.Decode(ptr.Interface()); err != nil {
+		return err
+	}
+
+	if holdsInterface(ptr.Type(), map[reflect.Type]bool{}) {
+		setNumbers(ptr)
+	}
+
+	return nil
+}
+
+// holdsInterface returns true if values of t can hold interface values.
+func holdsInterface(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsInterface(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if holdsInterface(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// setNumbers replaces the json.Number values held by interfaces within v with their int64 or float64 value.
+func setNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+
+		if n, ok := v.Interface().(json.Number); ok {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(number(n)))
+			}
+			return
+		}
+
+		setNumbers(v.Elem())
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			setNumbers(v.Elem())
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			setNumbers(v.Index(i))
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				setNumbers(v.Field(i))
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			setNumbers(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+}
+
+// number returns the int64 value of an integer number, or the float64 value of any other number.
+func number(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+
+	f, _ := n.Float64()
+	return f
+}
+
+// isListType returns true if values of t, or the type it points to, are output as JSON arrays.
+func isListType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return isList(reflect.Zero(t))
+}
+
+// skipSpace returns the offset of the first non space byte from offset.
+func skipSpace(input []byte, offset int) int {
+	for offset < len(input) && (input[offset] == ' ' || input[offset] == '\t' || input[offset] == '\r' || input[offset] == '\n') {
+		offset++
+	}
+	return offset
+}
+
+// positionError adds the position of syntax and type errors to the error, start is the offset of the document
+// the error offset is relative to.  The position is that of the last byte read before the error.
+func positionError(input []byte, start int, err error) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	var offset int
+	switch {
+	case errors.As(err, &syntaxError):
+		offset = start + int(syntaxError.Offset) - 1
+	case errors.As(err, &typeError):
+		offset = start + int(typeError.Offset) - 1
+	default:
+		return err
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	line, column := position(input, offset)
+
+	return &yaff.DecodeError{Line: line, Column: column, Err: err}
+}
+
+// position returns the line and column of the byte offset in the input.
+func position(input []byte, offset int) (int, int) {
+	if offset > len(input) {
+		offset = len(input)
+	}
+
+	before := input[:offset]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+
+	return bytes.Count(before, []byte("\n")) + 1, utf8.RuneCount(before[lineStart:]) + 1
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonformatter
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nehemming/yaff"
+)
+
+func decodeRows() []testData {
+	return []testData{
+		{S: "a", I: 1, F: 1.5, N: innerData{Sin: "x"}},
+		{S: "b", I: 2},
+		{S: "c", I: 3},
+	}
+}
+
+func roundTrip(t *testing.T, format yaff.Format, options yaff.FormatOptions, data interface{}, target interface{}) {
+	t.Helper()
+
+	fmt, err := yaff.Formatters().GetFormatter(format)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	dec, err := yaff.Formatters().GetDecoder(format)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if err := dec.Decode(&buf, options, target); err != nil {
+		t.Errorf("Decode Error %v", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var got []testData
+	roundTrip(t, JSON, nil, decodeRows(), &got)
+
+	if !reflect.DeepEqual(got, decodeRows()) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeJSONSingle(t *testing.T) {
+	var got testData
+	roundTrip(t, JSON, nil, decodeRows()[0], &got)
+
+	if got != decodeRows()[0] {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeJSONEnvelope(t *testing.T) {
+	options := NewOptions()
+	options.Envelope = true
+	options.Offset = 1
+
+	var got []*testData
+	roundTrip(t, JSON, options, decodeRows(), &got)
+
+	if len(got) != 2 || *got[0] != decodeRows()[1] {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeJSONLines(t *testing.T) {
+	var got []testData
+	roundTrip(t, JSONLines, nil, decodeRows(), &got)
+
+	if !reflect.DeepEqual(got, decodeRows()) {
+		t.Errorf("got %v", got)
+	}
+
+	var lists [][]int
+	roundTrip(t, NDJSON, nil, [][]int{{1, 2}, {3}}, &lists)
+
+	if !reflect.DeepEqual(lists, [][]int{{1, 2}, {3}}) {
+		t.Errorf("got %v", lists)
+	}
+}
+
+func TestDecodeJSONNumbers(t *testing.T) {
+	dec, err := NewDecoder()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var got []map[string]interface{}
+	err = dec.Decode(strings.NewReader(`[{"id":12345678901234567,"ratio":0.5,"tags":[1,{"n":2}]}]`), nil, &got)
+	if err != nil {
+		t.Errorf("Decode Error %v", err)
+	}
+
+	expected := []map[string]interface{}{
+		{"id": int64(12345678901234567), "ratio": 0.5, "tags": []interface{}{int64(1), map[string]interface{}{"n": int64(2)}}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	dec, _ := NewLinesDecoder()
+
+	for input, exp := range map[string][2]int{
+		"{\"S\":\"a\"}\n{\"S\":\"b\",\"I\":\"x\"}\n": {2, 16},
+		"{\"S\":\"a\"}\n{\"S\":\"b\",}\n":            {2, 10},
+	} {
+		var got []testData
+		err := dec.Decode(strings.NewReader(input), nil, &got)
+
+		var decodeError *yaff.DecodeError
+		if !errors.As(err, &decodeError) || decodeError.Line != exp[0] || decodeError.Column != exp[1] {
+			t.Errorf("input %q err %v", input, err)
+		}
+	}
+}
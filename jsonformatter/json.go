@@ -25,6 +25,7 @@ import (
 	"github.com/nehemming/lpax"
 	"github.com/nehemming/yaff"
 	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
 )
 
 // JSON format.
@@ -41,6 +42,16 @@ type formatter struct{}
 type Options struct {
 	Indent       int
 	IndentString string
+	// Lines streams rows as newline delimited JSON, one compact document per line,
+	// rather than as a single array.
+	Lines bool
+	// Offset is the number of rows skipped before output starts.
+	Offset int
+	// Limit is the maximum number of rows output, 0 for no limit.
+	Limit int
+	// Envelope wraps the rows in an object holding their total, offset and the items output.
+	// Streams and JSON lines are never wrapped.
+	Envelope bool
 }
 
 // NewOptions return new options.
@@ -51,7 +62,7 @@ func NewOptions() Options {
 	}
 }
 
-func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+func getOptions(options yaff.FormatOptions, format yaff.Format) (Options, error) {
 	if options == nil {
 		options = NewOptions()
 	}
@@ -59,7 +70,25 @@ func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ..
 	// convert options type
 	jsonOptions, ok := options.(Options)
 	if !ok {
-		return lpax.Errorf(langpack.ErrorInvalidOptionType, options, JSON)
+		return jsonOptions, lpax.Errorf(langpack.ErrorInvalidOptionType, options, format)
+	}
+
+	if jsonOptions.IndentString == "" {
+		jsonOptions.IndentString = " "
+	}
+
+	return jsonOptions, nil
+}
+
+// page returns the rows selected by the options.
+func (options Options) page() rowset.Page {
+	return rowset.Page{Offset: options.Offset, Limit: options.Limit}
+}
+
+func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	jsonOptions, err := getOptions(options, JSON)
+	if err != nil {
+		return err
 	}
 
 	// use JSON serialization, concat data into a single doc
@@ -71,21 +100,14 @@ func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ..
 		d = data
 	}
 
-	var buf []byte
-
-	var err error
-
-	ident := jsonOptions.IndentString
-	if ident == "" {
-		ident = " "
-	}
-
-	if jsonOptions.Indent > 0 {
-		buf, err = json.MarshalIndent(d, "", strings.Repeat(ident, jsonOptions.Indent))
+	// Page the rows, optionally wrapping them with their total
+	if jsonOptions.Envelope {
+		d = jsonOptions.page().Envelope(d)
 	} else {
-		buf, err = json.Marshal(d)
+		d, _ = jsonOptions.page().Apply(d)
 	}
 
+	buf, err := marshal(d, "", jsonOptions)
 	if err != nil {
 		return err
 	}
@@ -97,7 +119,17 @@ func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ..
 	return err
 }
 
+// marshal marshals d using the indent options, prefix is written at the start of each new line.
+func marshal(d interface{}, prefix string, options Options) ([]byte, error) {
+	if options.Indent > 0 {
+		return json.MarshalIndent(d, prefix, strings.Repeat(options.IndentString, options.Indent))
+	}
+
+	return json.Marshal(d)
+}
+
 func init() {
 	// Register this formatter
 	yaff.Formatters().Register(JSON, NewFormatter)
+	yaff.Formatters().RegisterDecoder(JSON, NewDecoder)
 }
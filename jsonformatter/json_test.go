@@ -123,3 +123,39 @@ func TestNewFormatterTwoOutputs(t *testing.T) {
 
 	testsupport.CompareStrings(t, expected, got)
 }
+
+func TestNewFormatterPage(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Indent = 0
+	options.Offset = 1
+	options.Limit = 1
+
+	var buf bytes.Buffer
+
+	if err := fmt.Format(&buf, options, []int{1, 2, 3}); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "[2]\n", buf.String())
+}
+
+func TestNewFormatterEnvelope(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Indent = 0
+	options.Limit = 2
+	options.Envelope = true
+
+	var buf bytes.Buffer
+
+	// Multiple data items are paged as a single array
+	if err := fmt.Format(&buf, options, "a", "b", "c"); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, `{"total":3,"offset":0,"items":["a","b"]}
+`, buf.String())
+}
@@ -0,0 +1,128 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonformatter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/nehemming/yaff"
+)
+
+const (
+	// JSONLines newline delimited JSON format, one compact JSON document per line.
+	JSONLines = yaff.Format("jsonl")
+
+	// NDJSON is an alternative name for the JSONLines format.
+	NDJSON = yaff.Format("ndjson")
+)
+
+// NewLinesFormatter return a new JSON Lines formatter.
+func NewLinesFormatter() (yaff.Formatter, error) {
+	return &linesFormatter{}, nil
+}
+
+type linesFormatter struct{}
+
+func (f *linesFormatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	return f.FormatContext(context.Background(), writer, options, data...)
+}
+
+func (f *linesFormatter) FormatContext(ctx context.Context, writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	// Options are accepted for consistency with JSON, lines are always compact
+	jsonOptions, err := getOptions(options, JSONLines)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(yaff.NewContextWriter(ctx, writer))
+
+	// The page is taken from the lines of all the data
+	page := jsonOptions.page()
+	line := -1
+	encode := func(d interface{}) error {
+		line++
+		if !page.Includes(line) {
+			return nil
+		}
+		return encodeLine(ctx, encoder, d)
+	}
+
+	for _, d := range data {
+		value := reflect.ValueOf(d)
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		// Slices and arrays are flattened into a line per item, byte slices are values in their own right
+		if !isList(value) {
+			if err := encode(d); err != nil {
+				return err
+			}
+			continue
+		}
+
+		n := value.Len()
+		for i := 0; i < n; i++ {
+			if err := encode(value.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *linesFormatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	jsonOptions, err := getOptions(options, JSONLines)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonOptions.Lines = true
+
+	return &stream{writer: writer, options: jsonOptions}, nil
+}
+
+func isList(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice:
+		fallthrough
+	case reflect.Array:
+		return value.Type().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+func encodeLine(ctx context.Context, encoder *json.Encoder, d interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return encoder.Encode(d)
+}
+
+func init() {
+	// Register this formatter under both names
+	yaff.Formatters().Register(JSONLines, NewLinesFormatter)
+	yaff.Formatters().Register(NDJSON, NewLinesFormatter)
+	yaff.Formatters().RegisterDecoder(JSONLines, NewLinesDecoder)
+	yaff.Formatters().RegisterDecoder(NDJSON, NewLinesDecoder)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+)
+
+func TestJSONLines(t *testing.T) {
+	if JSONLines != yaff.Format("jsonl") {
+		t.Errorf("Bad Format name %v", JSONLines)
+	}
+
+	if NDJSON != yaff.Format("ndjson") {
+		t.Errorf("Bad Format name %v", NDJSON)
+	}
+}
+
+func TestJSONLinesRegistered(t *testing.T) {
+	for _, format := range []yaff.Format{JSONLines, NDJSON} {
+		if _, err := yaff.Formatters().GetFormatter(format); err != nil {
+			t.Errorf("Error %v (%v)", err, format)
+		}
+	}
+}
+
+func TestNewLinesFormatterFlattens(t *testing.T) {
+	fmt, err := NewLinesFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, nil,
+		[]testData{
+			{S: "Hello", I: 10, F: 3.14, N: innerData{Sin: "Inside"}},
+			{S: "Again", I: 99, F: 2.71},
+		},
+		&testData{S: "Single", I: 1},
+		[2]int{1, 2},
+		[]byte("raw"),
+		[]string(nil),
+	)
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `{"S":"Hello","I":10,"F":3.14,"N":{"Sin":"Inside"}}
+{"S":"Again","I":99,"F":2.71,"N":{"Sin":""}}
+{"S":"Single","I":1,"F":0,"N":{"Sin":""}}
+1
+2
+"cmF3"
+`
+
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewLinesFormatterIgnoresIndent(t *testing.T) {
+	fmt, _ := NewLinesFormatter()
+
+	options := NewOptions()
+	options.Indent = 4
+
+	var buf bytes.Buffer
+
+	if err := fmt.Format(&buf, options, &testData{S: "Hello"}); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, `{"S":"Hello","I":0,"F":0,"N":{"Sin":""}}
+`, buf.String())
+}
+
+func TestNewLinesFormatterBadOptions(t *testing.T) {
+	fmt, _ := NewLinesFormatter()
+
+	if err := fmt.Format(&bytes.Buffer{}, "bad", 1); err == nil {
+		t.Error("No option error")
+	}
+}
+
+func TestLinesStream(t *testing.T) {
+	fmt, _ := NewLinesFormatter()
+
+	var buf bytes.Buffer
+
+	stream, err := fmt.(yaff.StreamFormatter).NewStream(&buf, nil)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	_ = stream.Write(testData{S: "One"})
+	_ = stream.Write(testData{S: "Two"})
+	_ = stream.Close()
+
+	expected := `{"S":"One","I":0,"F":0,"N":{"Sin":""}}
+{"S":"Two","I":0,"F":0,"N":{"Sin":""}}
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewLinesFormatterPage(t *testing.T) {
+	fmt, _ := NewLinesFormatter()
+
+	options := NewOptions()
+	options.Offset = 1
+	options.Limit = 2
+
+	var buf bytes.Buffer
+
+	// The page spans the lines of all the data
+	if err := fmt.Format(&buf, options, []int{1, 2}, []int{3, 4}); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "2\n3\n", buf.String())
+}
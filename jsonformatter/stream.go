@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonformatter
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+)
+
+type stream struct {
+	writer  io.Writer
+	options Options
+	prefix  string
+	rows    int
+	// seen is the number of rows received, including those outside the page.
+	seen   int
+	closed bool
+}
+
+func (f *formatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	jsonOptions, err := getOptions(options, JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stream{
+		writer:  writer,
+		options: jsonOptions,
+		prefix:  strings.Repeat(jsonOptions.IndentString, jsonOptions.Indent),
+	}, nil
+}
+
+func (s *stream) Write(row interface{}) error {
+	if s.closed {
+		return lpax.Errorf(langpack.ErrorStreamClosed)
+	}
+
+	s.seen++
+	if !s.options.page().Includes(s.seen - 1) {
+		return nil
+	}
+
+	if s.options.Lines {
+		return s.writeLine(row)
+	}
+
+	// Rows are written as elements of an array, opened by the first row
+	buf, err := marshal(row, s.prefix, s.options)
+	if err != nil {
+		return err
+	}
+
+	sep := ","
+	if s.rows == 0 {
+		sep = "["
+	}
+	if s.options.Indent > 0 {
+		sep += "\n" + s.prefix
+	}
+
+	s.rows++
+
+	if _, err := s.writer.Write([]byte(sep)); err != nil {
+		return err
+	}
+
+	_, err = s.writer.Write(buf)
+	return err
+}
+
+func (s *stream) writeLine(row interface{}) error {
+	buf, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	s.rows++
+
+	_, err = s.writer.Write(append(buf, '\n'))
+	return err
+}
+
+func (s *stream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if s.options.Lines {
+		return nil
+	}
+
+	// Close the array
+	end := "]\n"
+	if s.rows == 0 {
+		end = "[]\n"
+	} else if s.options.Indent > 0 {
+		end = "\n]\n"
+	}
+
+	_, err := s.writer.Write([]byte(end))
+	return err
+}
@@ -0,0 +1,91 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+)
+
+func streamRows(t *testing.T, options Options, rows ...interface{}) string {
+	t.Helper()
+
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, options)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return ""
+	}
+
+	for _, row := range rows {
+		if err := stream.Write(row); err != nil {
+			t.Errorf("Write Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestStreamMatchesFormat(t *testing.T) {
+	for _, indent := range []int{0, 2} {
+		options := NewOptions()
+		options.Indent = indent
+
+		rows := []interface{}{
+			&testData{S: "Hello", I: 10, F: 3.14, N: innerData{Sin: "Inside"}},
+			&testData{S: "Again", I: 99, F: 2.71},
+		}
+
+		f, _ := NewFormatter()
+
+		var buf bytes.Buffer
+		if err := f.Format(&buf, options, rows...); err != nil {
+			t.Errorf("Formatter Error %v", err)
+		}
+
+		testsupport.CompareStrings(t, buf.String(), streamRows(t, options, rows...))
+	}
+}
+
+func TestStreamEmpty(t *testing.T) {
+	testsupport.CompareStrings(t, "[]\n", streamRows(t, NewOptions()))
+}
+
+func TestStreamLines(t *testing.T) {
+	options := NewOptions()
+	options.Lines = true
+
+	got := streamRows(t, options, testData{S: "Hello", I: 10}, testData{S: "Again", I: 99})
+
+	expected := `{"S":"Hello","I":10,"F":0,"N":{"Sin":""}}
+{"S":"Again","I":99,"F":0,"N":{"Sin":""}}
+`
+	testsupport.CompareStrings(t, expected, got)
+}
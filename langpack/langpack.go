@@ -73,6 +73,138 @@ const (
 
 	// ErrorRowInvalidID Row has an invalid ID.
 	ErrorRowInvalidID
+
+	// ErrorUnknownColor unknown text color.
+	ErrorUnknownColor
+
+	// ErrorStreamingNotSupported formatter cannot stream.
+	ErrorStreamingNotSupported
+
+	// ErrorStreamClosed stream already closed.
+	ErrorStreamClosed
+
+	// ErrorStreamRow stream row cannot be written as a record.
+	ErrorStreamRow
+
+	// ErrorStreamRowType stream row has a different type to the first row.
+	ErrorStreamRowType
+
+	// ErrorUnknownField field name cannot be resolved.
+	ErrorUnknownField
+
+	// ErrorNotSortable value cannot be sorted.
+	ErrorNotSortable
+
+	// ErrorFilterSyntax filter expression is invalid.
+	ErrorFilterSyntax
+
+	// ErrorFilterNotBoolean filter expression is not a boolean.
+	ErrorFilterNotBoolean
+
+	// ErrorUnknownAggregate aggregate name is not known.
+	ErrorUnknownAggregate
+
+	// ErrorAggregateNotNumeric aggregate needs a numeric column.
+	ErrorAggregateNotNumeric
+
+	// ErrorInvalidSeparator csv separator is not a single character.
+	ErrorInvalidSeparator
+
+	// ErrorDecodingNotSupported format has no decoder.
+	ErrorDecodingNotSupported
+
+	// ErrorDecodeTarget decode target is not a pointer.
+	ErrorDecodeTarget
+
+	// ErrorDecodeRecord records cannot be decoded into the type.
+	ErrorDecodeRecord
+
+	// ErrorDecodeLine decoding error on a line.
+	ErrorDecodeLine
+
+	// ErrorDecodePosition decoding error at a line and column.
+	ErrorDecodePosition
+
+	// ErrorCellText cell text cannot be decoded into a value.
+	ErrorCellText
+
+	// ErrorFieldCount record has the wrong number of fields.
+	ErrorFieldCount
+
+	// ErrorBareQuote quote in an unquoted field.
+	ErrorBareQuote
+
+	// ErrorQuote extraneous or missing quote in a quoted field.
+	ErrorQuote
+
+	// ErrorStyleNotDecodable text style cannot be decoded.
+	ErrorStyleNotDecodable
+
+	// ErrorUnknownColumns header names columns the record does not have.
+	ErrorUnknownColumns
+
+	// TextShowingRows trailer written beneath a page of rows.
+	TextShowingRows
+
+	// TextShowingNoRows trailer written when a page has no rows.
+	TextShowingNoRows
+
+	// TextRecord title of a vertical record.
+	TextRecord
+
+	// TextTotal label of a totals row of mixed aggregates or the grand total of grouped rows.
+	TextTotal
+
+	// TextSubtotal label of the totals row of a group of rows.
+	TextSubtotal
+
+	// TextSum label of a totals row of sums.
+	TextSum
+
+	// TextAvg label of a totals row of averages.
+	TextAvg
+
+	// TextMin label of a totals row of minimums.
+	TextMin
+
+	// TextMax label of a totals row of maximums.
+	TextMax
+
+	// TextCount label of a totals row of counts.
+	TextCount
+
+	// TextGroupValue column name and value in the heading of a group of rows.
+	TextGroupValue
+
+	// TextGroupSeparator separator of the column values in the heading of a group of rows.
+	TextGroupSeparator
+
+	// TextRelativeNow relative time of the current time.
+	TextRelativeNow
+
+	// TextRelativePast relative time of a time in the past, i.e. 3 hours ago.
+	TextRelativePast
+
+	// TextRelativeFuture relative time of a time in the future, i.e. in 2 days.
+	TextRelativeFuture
+
+	// TextYears number of years of a relative time.
+	TextYears
+
+	// TextMonths number of months of a relative time.
+	TextMonths
+
+	// TextDays number of days of a relative time.
+	TextDays
+
+	// TextHours number of hours of a relative time.
+	TextHours
+
+	// TextMinutes number of minutes of a relative time.
+	TextMinutes
+
+	// TextSeconds number of seconds of a relative time.
+	TextSeconds
 )
 
 var languagePack = lpax.TextMap{
@@ -84,6 +216,64 @@ var languagePack = lpax.TextMap{
 
 	ErrorColumnInvalidID: "Column %d is an invalid id",
 	ErrorRowInvalidID:    "Row %d is an invalid id",
+
+	ErrorUnknownColor: "Unknown color %v",
+
+	ErrorStreamingNotSupported: "Formatter %s does not support streaming",
+	ErrorStreamClosed:          "Stream is closed",
+	ErrorStreamRow:             "Stream row of type %T cannot be written as a record, write each item of a list",
+	ErrorStreamRowType:         "Stream row of type %v does not match type %v of the first row",
+
+	ErrorUnknownField: "Unknown field %s",
+	ErrorNotSortable:  "Type %v is not a slice or array and cannot be sorted",
+
+	ErrorFilterSyntax:        "Filter syntax error at position %d in %q",
+	ErrorFilterNotBoolean:    "Filter value %v is not true or false",
+	ErrorUnknownAggregate:    "Unknown aggregate %q, expected sum, avg, min, max or count",
+	ErrorAggregateNotNumeric: "Column %s is not numeric and cannot be aggregated by %s",
+	ErrorInvalidSeparator:    "Column separator %q must be a single character other than a quote or line break",
+
+	ErrorDecodingNotSupported: "Format %s does not support decoding",
+	ErrorDecodeTarget:         "Decode target %T is not a non nil pointer",
+	ErrorDecodeRecord:         "Records cannot be decoded into type %v",
+	ErrorDecodeLine:           "Line %d: %v",
+	ErrorDecodePosition:       "Line %d, column %d: %v",
+	ErrorCellText:             "Cannot decode %q as %v",
+	ErrorFieldCount:           "Record has %d fields, expected %d",
+	ErrorBareQuote:            "Quote in unquoted field",
+	ErrorQuote:                "Extraneous or missing quote in quoted field",
+	ErrorStyleNotDecodable:    "Text style %v cannot be decoded, only plain and aligned text can",
+	ErrorUnknownColumns:       "Unknown columns %s for type %v",
+
+	TextShowingRows:   "Showing rows %d to %d of %d",
+	TextShowingNoRows: "Showing 0 of %d rows",
+	TextRecord:        "RECORD %d",
+	TextTotal:         "Total",
+	TextSubtotal:      "Subtotal",
+	TextSum:           "Sum",
+	TextAvg:           "Avg",
+	TextMin:           "Min",
+	TextMax:           "Max",
+	TextCount:         "Count",
+
+	TextGroupValue:     "%s: %s",
+	TextGroupSeparator: ", ",
+
+	TextRelativeNow:    "now",
+	TextRelativePast:   "%s ago",
+	TextRelativeFuture: "in %s",
+	TextYears:          "%d year",
+	-TextYears:         "%d years",
+	TextMonths:         "%d month",
+	-TextMonths:        "%d months",
+	TextDays:           "%d day",
+	-TextDays:          "%d days",
+	TextHours:          "%d hour",
+	-TextHours:         "%d hours",
+	TextMinutes:        "%d minute",
+	-TextMinutes:       "%d minutes",
+	TextSeconds:        "%d second",
+	-TextSeconds:       "%d seconds",
 }
 
 func init() {
@@ -23,7 +23,7 @@ import (
 	"github.com/nehemming/yaff/langpack"
 )
 
-// Registry holds a list of available formatters.
+// Registry holds a list of available formatters and decoders.
 // It is possible to create multiple formatters, but by default the
 // default shared yaff.Formatters registry can be used
 // Registry implementors must be threadsafe across all calls.
@@ -37,12 +37,31 @@ type Registry interface {
 	// GetFormatter returns the formatter supporting the format or an error if no formatter can be found.
 	GetFormatter(format Format) (Formatter, error)
 
+	// GetContextFormatter returns a context aware formatter supporting the format or an error if no formatter can be found.
+	// Formatters that do not implement ContextFormatter are adapted using WithContext.
+	GetContextFormatter(format Format) (ContextFormatter, error)
+
+	// GetStreamFormatter returns the stream formatter supporting the format or an error if
+	// no formatter can be found or the formatter does not support streaming.
+	GetStreamFormatter(format Format) (StreamFormatter, error)
+
 	// Formats returns a slice of supported formats.
 	Formats() []Format
+
+	// RegisterDecoder adds or updates the decoder reading a format.
+	// The NewDecoder factory is used to create an instance of the registered decoder.
+	RegisterDecoder(format Format, factory NewDecoder)
+
+	// GetDecoder returns the decoder reading the format or an error if no decoder can be found.
+	GetDecoder(format Format) (Decoder, error)
+
+	// DecoderFormats returns a slice of the formats that can be decoded.
+	DecoderFormats() []Format
 }
 
 type registry struct {
 	factories map[Format]NewFormatter
+	decoders  map[Format]NewDecoder
 	mu        sync.Mutex
 }
 
@@ -50,6 +69,7 @@ type registry struct {
 func NewRegistry() Registry {
 	return &registry{
 		factories: make(map[Format]NewFormatter),
+		decoders:  make(map[Format]NewDecoder),
 	}
 }
 
@@ -88,6 +108,29 @@ func (r *registry) GetFormatter(format Format) (Formatter, error) {
 	return factory()
 }
 
+func (r *registry) GetContextFormatter(format Format) (ContextFormatter, error) {
+	formatter, err := r.GetFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithContext(formatter), nil
+}
+
+func (r *registry) GetStreamFormatter(format Format) (StreamFormatter, error) {
+	formatter, err := r.GetFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+
+	streamFormatter, ok := formatter.(StreamFormatter)
+	if !ok {
+		return nil, lpax.Errorf(langpack.ErrorStreamingNotSupported, format)
+	}
+
+	return streamFormatter, nil
+}
+
 func (r *registry) getFactory(format Format) NewFormatter {
 	// Lock to maintain thread safety
 	r.mu.Lock()
@@ -96,6 +139,43 @@ func (r *registry) getFactory(format Format) NewFormatter {
 	return r.factories[format]
 }
 
+func (r *registry) RegisterDecoder(format Format, factory NewDecoder) {
+	// Lock to maintain thread safety
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Passing nil will "deregister" the factory
+	r.decoders[format] = factory
+}
+
+func (r *registry) GetDecoder(format Format) (Decoder, error) {
+	// Lock to find the factory, but keep the call to the factory outside the lock
+	r.mu.Lock()
+	factory := r.decoders[format]
+	r.mu.Unlock()
+
+	if factory == nil {
+		return nil, lpax.Errorf(langpack.ErrorDecodingNotSupported, format)
+	}
+
+	return factory()
+}
+
+func (r *registry) DecoderFormats() []Format {
+	// Lock to maintain thread safety.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := make([]Format, len(r.decoders))
+	i := 0
+	for k := range r.decoders {
+		c[i] = k
+		i++
+	}
+
+	return c
+}
+
 var sharedRegistry = NewRegistry()
 
 // Formatters is the shared registry of formatters.
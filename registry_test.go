@@ -134,3 +134,107 @@ func TestSharedRegistry(t *testing.T) {
 		t.Errorf("Formats too long %v", len(formats))
 	}
 }
+
+func TestGetStreamFormatterNotSupported(t *testing.T) {
+	reg := NewRegistry()
+
+	testFormat := Format("test")
+
+	reg.Register(testFormat, func() (Formatter, error) {
+		return &testFormatter{}, nil
+	})
+
+	f, err := reg.GetStreamFormatter(testFormat)
+	if err == nil {
+		t.Error("No error for non streaming formatter")
+	}
+
+	if f != nil {
+		t.Error("Magic stream formatter exists")
+	}
+
+	if _, err := reg.GetStreamFormatter(Format("unknown")); err == nil {
+		t.Error("No error for unknown formatter")
+	}
+}
+
+type testStreamFormatter struct {
+	testFormatter
+}
+
+func (f *testStreamFormatter) NewStream(writer io.Writer, options FormatOptions) (Stream, error) {
+	return nil, nil
+}
+
+func TestGetStreamFormatter(t *testing.T) {
+	reg := NewRegistry()
+
+	testFormat := Format("test")
+
+	reg.Register(testFormat, func() (Formatter, error) {
+		return &testStreamFormatter{}, nil
+	})
+
+	f, err := reg.GetStreamFormatter(testFormat)
+	if err != nil {
+		t.Errorf("Error get %v", err)
+	}
+
+	if f == nil {
+		t.Error("No stream formatter")
+	}
+}
+
+type testDecoder struct{}
+
+func (d *testDecoder) Decode(reader io.Reader, options FormatOptions, target interface{}) error {
+	return nil
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	reg := NewRegistry()
+
+	testFormat := Format("test")
+
+	if d, err := reg.GetDecoder(testFormat); err == nil || d != nil {
+		t.Error("Magic decoder exists")
+	}
+
+	reg.RegisterDecoder(testFormat, func() (Decoder, error) {
+		return &testDecoder{}, nil
+	})
+
+	if formats := reg.DecoderFormats(); len(formats) != 1 || formats[0] != testFormat {
+		t.Errorf("DecoderFormats %v", formats)
+	}
+
+	if len(reg.Formats()) != 0 {
+		t.Error("Decoder registered as a formatter")
+	}
+
+	d, err := reg.GetDecoder(testFormat)
+	if err != nil {
+		t.Errorf("Error get %v", err)
+	}
+
+	if d == nil {
+		t.Error("No decoder")
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	inner := errors.New("bad")
+
+	err := error(&DecodeError{Line: 2, Column: 3, Err: inner})
+	if !errors.Is(err, inner) {
+		t.Error("Unwrap failed")
+	}
+
+	if err.Error() != "Line 2, column 3: bad" {
+		t.Errorf("Error %v", err)
+	}
+
+	if err := (&DecodeError{Line: 2, Err: inner}); err.Error() != "Line 2: bad" {
+		t.Errorf("Error %v", err)
+	}
+}
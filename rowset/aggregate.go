@@ -0,0 +1,264 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// Aggregate is a function summarising the values of a column.
+type Aggregate string
+
+const (
+	// NoAggregate leaves a column without a total.
+	NoAggregate = Aggregate("")
+	// Sum totals the values of a column.
+	Sum = Aggregate("sum")
+	// Avg averages the values of a column.
+	Avg = Aggregate("avg")
+	// Min is the smallest value of a column.
+	Min = Aggregate("min")
+	// Max is the largest value of a column.
+	Max = Aggregate("max")
+	// Count is the number of non nil values of a column.
+	Count = Aggregate("count")
+)
+
+// ParseAggregate returns the aggregate with the name s, ignoring case.
+func ParseAggregate(s string) (Aggregate, error) {
+	a := Aggregate(strings.ToLower(strings.Trim(s, " ")))
+
+	switch a {
+	case NoAggregate, Sum, Avg, Min, Max, Count:
+		return a, nil
+	default:
+		return NoAggregate, lpax.Errorf(langpack.ErrorUnknownAggregate, s)
+	}
+}
+
+// IsNumeric returns true if the aggregate needs numeric values.
+func (a Aggregate) IsNumeric() bool {
+	return a != NoAggregate && a != Count
+}
+
+// aggregateTexts are the labels of totals rows of each aggregate.
+var aggregateTexts = map[Aggregate]langpack.TextID{
+	Sum:   langpack.TextSum,
+	Avg:   langpack.TextAvg,
+	Min:   langpack.TextMin,
+	Max:   langpack.TextMax,
+	Count: langpack.TextCount,
+}
+
+// Label returns the label of a totals row, the title of the aggregate if
+// they are all the same, otherwise Total.
+func Label(aggregates ...Aggregate) string {
+	label := NoAggregate
+
+	for _, a := range aggregates {
+		switch {
+		case a == NoAggregate:
+		case label == NoAggregate:
+			label = a
+		case label != a:
+			return lpax.Sprintf(langpack.TextTotal)
+		}
+	}
+
+	if label == NoAggregate {
+		return lpax.Sprintf(langpack.TextTotal)
+	}
+
+	return lpax.Sprintf(aggregateTexts[label])
+}
+
+// Accumulator accumulates the values of a column for an aggregate.
+// Signed integers are summed as int64, unsigned integers as uint64 and
+// floating point numbers as float64, so integer totals are exact.
+type Accumulator struct {
+	aggregate Aggregate
+	count     int
+	kind      reflect.Kind
+	intSum    int64
+	uintSum   uint64
+	floatSum  float64
+	min       reflect.Value
+	max       reflect.Value
+	valueType reflect.Type
+}
+
+// NewAccumulator returns an accumulator for the aggregate a.
+func NewAccumulator(a Aggregate) *Accumulator {
+	return &Accumulator{aggregate: a}
+}
+
+// Aggregate returns the aggregate being accumulated.
+func (acc *Accumulator) Aggregate() Aggregate {
+	return acc.aggregate
+}
+
+// Type returns the type of the numeric values added, nil if none have been.
+func (acc *Accumulator) Type() reflect.Type {
+	return acc.valueType
+}
+
+// Add adds a value, pointers are followed and nil values are ignored as are
+// non numeric values by numeric aggregates.
+func (acc *Accumulator) Add(v reflect.Value) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return
+	}
+
+	if !acc.aggregate.IsNumeric() {
+		acc.count++
+		return
+	}
+
+	n, ok := Number(v)
+	if !ok {
+		return
+	}
+
+	// Copy v so the minimum and maximum are not changed with the row.
+	v = v.Convert(v.Type())
+
+	kind := numberKind(v.Kind())
+	if acc.valueType == nil {
+		acc.valueType = v.Type()
+		acc.kind = kind
+		acc.min, acc.max = v, v
+	} else if kind != acc.kind {
+		// Mixed signed, unsigned and floating point values are summed as float64.
+		acc.kind = reflect.Float64
+	}
+
+	acc.count++
+	acc.floatSum += n
+	switch kind {
+	case reflect.Int64:
+		acc.intSum += v.Int()
+	case reflect.Uint64:
+		acc.uintSum += v.Uint()
+	}
+
+	if less(v, acc.min) {
+		acc.min = v
+	}
+	if less(acc.max, v) {
+		acc.max = v
+	}
+}
+
+// Result returns the aggregate of the values added, ok is false if there is no result.
+// Minimums and maximums have the type of the values added.  Sums are int64, uint64 or
+// float64, unless the values added have a 64 bit type, such as int or time.Duration,
+// which the sum keeps.  Averages of floating point and named types keep the type of
+// the values added, other averages are float64.
+func (acc *Accumulator) Result() (result reflect.Value, ok bool) {
+	if acc.aggregate == Count {
+		return reflect.ValueOf(acc.count), true
+	}
+
+	if acc.count == 0 || acc.aggregate == NoAggregate {
+		return reflect.Value{}, false
+	}
+
+	switch acc.aggregate {
+	case Sum:
+		return acc.sum(), true
+	case Min:
+		return acc.min, true
+	case Max:
+		return acc.max, true
+	default:
+		n := acc.floatSum / float64(acc.count)
+
+		k := acc.valueType.Kind()
+		if k != reflect.Float32 && k != reflect.Float64 && acc.valueType.PkgPath() == "" {
+			return reflect.ValueOf(n), true
+		}
+
+		return reflect.ValueOf(n).Convert(acc.valueType), true
+	}
+}
+
+// sum returns the total, converted to the type of the values added if that
+// cannot narrow it.
+func (acc *Accumulator) sum() reflect.Value {
+	var sum reflect.Value
+	switch acc.kind {
+	case reflect.Int64:
+		sum = reflect.ValueOf(acc.intSum)
+	case reflect.Uint64:
+		sum = reflect.ValueOf(acc.uintSum)
+	default:
+		sum = reflect.ValueOf(acc.floatSum)
+	}
+
+	if numberKind(acc.valueType.Kind()) == acc.kind && acc.valueType.Size() == sum.Type().Size() {
+		return sum.Convert(acc.valueType)
+	}
+
+	return sum
+}
+
+// numberKind returns the kind used to sum numbers of kind k.
+func numberKind(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int64
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint64
+	default:
+		return reflect.Float64
+	}
+}
+
+// less returns true if the number a is less than the number b, comparing
+// integers of the same signedness exactly.
+func less(a, b reflect.Value) bool {
+	ka, kb := numberKind(a.Kind()), numberKind(b.Kind())
+	switch {
+	case ka == reflect.Int64 && kb == reflect.Int64:
+		return a.Int() < b.Int()
+	case ka == reflect.Uint64 && kb == reflect.Uint64:
+		return a.Uint() < b.Uint()
+	}
+
+	x, _ := Number(a)
+	y, _ := Number(b)
+	return x < y
+}
+
+// Number returns the value of a number as a float64, ok is false if v is not a number.
+func Number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+func TestParseAggregate(t *testing.T) {
+	if a, err := ParseAggregate(" SUM "); err != nil || a != Sum {
+		t.Errorf("Unexpected %v %v", a, err)
+	}
+
+	_, err := ParseAggregate("median")
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorUnknownAggregate, "median") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	cases := map[string][]Aggregate{
+		"Sum":   {Sum, Sum},
+		"Count": {NoAggregate, Count},
+		"Total": {Sum, Avg},
+	}
+
+	for expected, aggregates := range cases {
+		if got := Label(aggregates...); got != expected {
+			t.Errorf("%v: expected %s got %s", aggregates, expected, got)
+		}
+	}
+
+	if got := Label(); got != lpax.Sprintf(langpack.TextTotal) {
+		t.Errorf("Unexpected empty label %s", got)
+	}
+}
+
+func accumulate(a Aggregate, values ...interface{}) *Accumulator {
+	acc := NewAccumulator(a)
+	for _, v := range values {
+		acc.Add(reflect.ValueOf(v))
+	}
+	return acc
+}
+
+func TestAccumulator(t *testing.T) {
+	three := 3
+
+	cases := []struct {
+		acc      *Accumulator
+		expected interface{}
+	}{
+		{accumulate(Sum, 1, 2, &three, (*int)(nil)), 6},
+		{accumulate(Avg, 1, 2), 1.5},
+		{accumulate(Min, 2.5, -1.0, 7.0), -1.0},
+		{accumulate(Max, uint8(4), uint8(9)), uint8(9)},
+		{accumulate(Avg, time.Second, 2*time.Second), 1500 * time.Millisecond},
+		{accumulate(Count, "a", nil, "b", (*int)(nil)), 2},
+		{accumulate(Sum, "a", 2), 2},
+		{accumulate(Count), 0},
+		{accumulate(Sum, int8(100), int8(100), int8(100)), int64(300)},
+		{accumulate(Sum, int64(1)<<53, int64(1)), int64(1)<<53 + 1},
+		{accumulate(Max, int64(1)<<53, int64(1)<<53+1), int64(1)<<53 + 1},
+		{accumulate(Sum, uint16(60000), uint16(60000)), uint64(120000)},
+		{accumulate(Sum, float32(1.5), float32(2)), 3.5},
+		{accumulate(Sum, time.Second, time.Minute), 61 * time.Second},
+		{accumulate(Sum, 1, 0.5), 1.5},
+	}
+
+	for i, c := range cases {
+		result, ok := c.acc.Result()
+		if !ok || result.Interface() != c.expected {
+			t.Errorf("%d: expected %v(%T) got %v %v", i, c.expected, c.expected, result, ok)
+		}
+	}
+
+	if _, ok := accumulate(Sum, "a").Result(); ok {
+		t.Error("Unexpected result without numbers")
+	}
+}
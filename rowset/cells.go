@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/nehemming/yaff"
+)
+
+var (
+	cellFormatterType = reflect.TypeOf((*yaff.CellFormatter)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	// declaredMethods caches whether a struct type declares the methods of an interface type.
+	declaredMethods sync.Map
+)
+
+// HasCellText returns true if values of t, or pointers to them, implement yaff.CellFormatter,
+// fmt.Stringer or encoding.TextMarshaler.  Methods promoted from the embedded fields of a struct
+// are ignored, so a struct embedding time.Time is not a cell value unless it declares its own.
+func HasCellText(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, it := range []reflect.Type{cellFormatterType, stringerType, textMarshalerType} {
+		if declares(t, it) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declares returns true if t, or a pointer to t, implements the interface it with methods it declares
+// rather than methods promoted from an embedded field.
+func declares(t, it reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	if !t.Implements(it) && !pt.Implements(it) {
+		return false
+	}
+
+	// Only structs have promoted methods
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+
+	key := [2]reflect.Type{t, it}
+	if declared, ok := declaredMethods.Load(key); ok {
+		return declared.(bool)
+	}
+
+	declared := true
+	for i := 0; i < it.NumMethod() && declared; i++ {
+		m, ok := t.MethodByName(it.Method(i).Name)
+		if !ok {
+			m, _ = pt.MethodByName(it.Method(i).Name)
+		}
+
+		declared = !isPromoted(m)
+	}
+
+	declaredMethods.Store(key, declared)
+	return declared
+}
+
+// isPromoted returns true if the method is promoted from an embedded field, the compiler
+// generates the wrapper methods calling the embedded field's method.
+func isPromoted(m reflect.Method) bool {
+	pc := m.Func.Pointer()
+
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return false
+	}
+
+	file, _ := fn.FileLine(pc)
+	return file == "<autogenerated>"
+}
+
+// CellText returns the text of a value using, in order of precedence, yaff.CellFormatter, fmt.Stringer
+// or encoding.TextMarshaler implemented with either value or pointer receivers.
+// ok is false if the value is nil or implements none of them.
+func CellText(v reflect.Value) (text string, align yaff.Alignment, ok bool) {
+	v = indirect(v)
+	if !v.IsValid() || !v.CanInterface() || !HasCellText(v.Type()) {
+		return "", yaff.AlignDefault, false
+	}
+
+	// Take the address so methods with pointer receivers are found too
+	var p reflect.Value
+	if v.CanAddr() {
+		p = v.Addr()
+	} else {
+		p = reflect.New(v.Type())
+		p.Elem().Set(v)
+	}
+
+	t := v.Type()
+
+	switch {
+	case declares(t, cellFormatterType):
+		text, align = p.Interface().(yaff.CellFormatter).FormatCell()
+		return text, align, true
+
+	case declares(t, stringerType):
+		return p.Interface().(fmt.Stringer).String(), yaff.AlignDefault, true
+
+	case declares(t, textMarshalerType):
+		if b, err := p.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(b), yaff.AlignDefault, true
+		}
+	}
+
+	return "", yaff.AlignDefault, false
+}
@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nehemming/yaff"
+)
+
+type money struct {
+	units    int64
+	currency string
+}
+
+func (m *money) FormatCell() (string, yaff.Alignment) {
+	return m.currency + " " + time.Duration(m.units).String(), yaff.AlignRight
+}
+
+type pointerStringer struct{ name string }
+
+func (p *pointerStringer) String() string {
+	return "ptr:" + p.name
+}
+
+type level int
+
+func (l level) MarshalText() ([]byte, error) {
+	return []byte([]string{"low", "high"}[l]), nil
+}
+
+func TestCellText(t *testing.T) {
+	ip := net.ParseIP("10.0.0.1")
+
+	cases := []struct {
+		value    interface{}
+		text     string
+		align    yaff.Alignment
+		expectOK bool
+	}{
+		{money{units: 1, currency: "GBP"}, "GBP 1ns", yaff.AlignRight, true},
+		{&money{units: 2, currency: "USD"}, "USD 2ns", yaff.AlignRight, true},
+		{pointerStringer{name: "a"}, "ptr:a", yaff.AlignDefault, true},
+		{level(1), "high", yaff.AlignDefault, true},
+		{ip, "10.0.0.1", yaff.AlignDefault, true},
+		{(*money)(nil), "", yaff.AlignDefault, false},
+		{10, "", yaff.AlignDefault, false},
+	}
+
+	for _, c := range cases {
+		text, align, ok := CellText(reflect.ValueOf(c.value))
+		if text != c.text || align != c.align || ok != c.expectOK {
+			t.Errorf("%#v: unexpected %q %v %v", c.value, text, align, ok)
+		}
+	}
+}
+
+type stamped struct {
+	time.Time
+	Name string
+}
+
+type namedStamp struct {
+	time.Time
+}
+
+func (n namedStamp) String() string {
+	return "stamp"
+}
+
+func TestHasCellText(t *testing.T) {
+	if HasCellText(reflect.TypeOf(stamped{})) || HasCellText(reflect.TypeOf(&stamped{})) {
+		t.Error("Promoted methods found")
+	}
+
+	if !HasCellText(reflect.TypeOf(namedStamp{})) {
+		t.Error("Declared method not found")
+	}
+
+	if text, _, ok := CellText(reflect.ValueOf(namedStamp{})); !ok || text != "stamp" {
+		t.Errorf("Unexpected text %q %v", text, ok)
+	}
+
+	if !HasCellText(reflect.TypeOf(pointerStringer{})) || !HasCellText(reflect.TypeOf(&money{})) {
+		t.Error("Pointer receivers not found")
+	}
+
+	if HasCellText(reflect.TypeOf(testRow{})) {
+		t.Error("Unexpected cell text")
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ColumnSpec selects a column for output.
+type ColumnSpec struct {
+	// Field is the name or dotted path of the field, resolved using FieldIndex.
+	Field string
+
+	// Alias replaces the column header when set.
+	Alias string
+}
+
+// aliasSeparator separates a field from its alias.
+const aliasSeparator = " as "
+
+// ParseColumnSpecs parses a comma separated, ordered list of columns.
+// A column can be renamed by following it with "as" and the new name, i.e. "Name,Status as State,Embedded.IntTwo".
+func ParseColumnSpecs(spec string) []ColumnSpec {
+	specs := make([]ColumnSpec, 0, 4)
+
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.Trim(s, " ")
+		if s == "" {
+			continue
+		}
+
+		column := ColumnSpec{Field: s}
+
+		if i := strings.Index(strings.ToLower(s), aliasSeparator); i > 0 {
+			column.Field = strings.Trim(s[:i], " ")
+			column.Alias = strings.Trim(s[i+len(aliasSeparator):], " ")
+		}
+
+		specs = append(specs, column)
+	}
+
+	return specs
+}
+
+// Header returns the header of the column, its alias if set otherwise name.
+func (spec ColumnSpec) Header(name string) string {
+	if spec.Alias != "" {
+		return spec.Alias
+	}
+	return name
+}
+
+// StructFieldByIndex returns the nested struct field of t with the index sequence, following pointers.
+func StructFieldByIndex(t reflect.Type, index []int) reflect.StructField {
+	var field reflect.StructField
+
+	for _, i := range index {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		field = t.Field(i)
+		t = field.Type
+	}
+
+	return field
+}
@@ -0,0 +1,45 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColumnSpecs(t *testing.T) {
+	specs := ParseColumnSpecs(" Name, Status AS State,,Embedded.IntTwo as Two Value")
+
+	expected := []ColumnSpec{
+		{Field: "Name"},
+		{Field: "Status", Alias: "State"},
+		{Field: "Embedded.IntTwo", Alias: "Two Value"},
+	}
+
+	if !reflect.DeepEqual(specs, expected) {
+		t.Errorf("unexpected specs %v", specs)
+	}
+}
+
+func TestStructFieldByIndex(t *testing.T) {
+	rt := reflect.TypeOf(&testRow{})
+
+	index, _ := FieldIndex(rt, "ptr.IntTwo")
+	if field := StructFieldByIndex(rt, index); field.Name != "IntTwo" {
+		t.Errorf("unexpected field %v", field.Name)
+	}
+}
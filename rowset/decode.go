@@ -0,0 +1,189 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// stringTimeLayout is the layout of the text output by time.Time.String.
+const stringTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// Target is the value records are decoded into.
+type Target struct {
+	value reflect.Value
+	slice bool
+}
+
+// NewTarget returns the target a decoder decodes records into, which must be a non nil pointer.
+// Records are appended to a slice, any other type is set by each record.
+func NewTarget(target interface{}) (*Target, error) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return nil, lpax.Errorf(langpack.ErrorDecodeTarget, target)
+	}
+
+	value = value.Elem()
+
+	return &Target{value: value, slice: value.Kind() == reflect.Slice}, nil
+}
+
+// Type returns the type of a record.
+func (t *Target) Type() reflect.Type {
+	if t.slice {
+		return t.value.Type().Elem()
+	}
+	return t.value.Type()
+}
+
+// New returns a new settable record.
+func (t *Target) New() reflect.Value {
+	return reflect.New(t.Type()).Elem()
+}
+
+// Add adds a record to the target.
+func (t *Target) Add(record reflect.Value) {
+	if t.slice {
+		t.value.Set(reflect.Append(t.value, record))
+		return
+	}
+	t.value.Set(record)
+}
+
+// Allocate follows pointers from the settable value v, allocating any that are nil, and returns the value pointed to.
+func Allocate(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// SettableField returns the nested field of the settable value v with the index sequence,
+// allocating nil pointers along the path.
+func SettableField(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		v = Allocate(v).Field(i)
+	}
+	return v
+}
+
+// SetCellText sets the settable value v from the text of a cell, pointers are allocated.
+// Times and durations are parsed from the text they are output as and types implementing
+// encoding.TextUnmarshaler decode themselves.
+func SetCellText(v reflect.Value, text string) error {
+	v = Allocate(v)
+
+	var err error
+
+	switch {
+	case v.Type() == timeType:
+		err = setTime(v, text)
+
+	case v.Type() == durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(text); err == nil {
+			v.SetInt(int64(d))
+		}
+
+	case reflect.PtrTo(v.Type()).Implements(textUnmarshalerType):
+		err = v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+
+	default:
+		err = setKind(v, text)
+	}
+
+	if err != nil {
+		return lpax.Errorf(langpack.ErrorCellText, text, v.Type())
+	}
+
+	return nil
+}
+
+// setKind sets a value of a basic kind from text.
+func setKind(v reflect.Value, text string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return strconv.ErrSyntax
+		}
+		v.Set(reflect.ValueOf(text))
+
+	default:
+		return strconv.ErrSyntax
+	}
+
+	return nil
+}
+
+// setTime sets a time from the text output by time.Time.String or any of the layouts filters compare times with.
+func setTime(v reflect.Value, text string) error {
+	t, err := time.Parse(stringTimeLayout, text)
+	if err != nil {
+		var ok bool
+		if t, ok = parseTime(text); !ok {
+			return err
+		}
+	}
+
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
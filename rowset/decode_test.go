@@ -0,0 +1,107 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+func TestNewTargetNotPointer(t *testing.T) {
+	var rows []int
+
+	for _, target := range []interface{}{rows, (*[]int)(nil), nil} {
+		if _, err := NewTarget(target); err == nil || err.Error() != lpax.Sprintf(langpack.ErrorDecodeTarget, target) {
+			t.Error("err:", err)
+		}
+	}
+}
+
+func TestTargetAdd(t *testing.T) {
+	var rows []int
+	var single int
+
+	for _, target := range []interface{}{&rows, &single} {
+		tgt, err := NewTarget(target)
+		if err != nil {
+			t.Errorf("Error %v", err)
+		}
+
+		for i := 1; i <= 2; i++ {
+			v := tgt.New()
+			v.SetInt(int64(i))
+			tgt.Add(v)
+		}
+	}
+
+	if !reflect.DeepEqual(rows, []int{1, 2}) || single != 2 {
+		t.Error("added:", rows, single)
+	}
+}
+
+type cellTextData struct {
+	S  string
+	B  *bool
+	I  int8
+	U  uint
+	F  float32
+	T  time.Time
+	D  time.Duration
+	IP net.IP
+	A  interface{}
+}
+
+func TestSetCellText(t *testing.T) {
+	var got cellTextData
+	v := reflect.ValueOf(&got).Elem()
+
+	for i, text := range []string{"s", "true", "-8", "8", "1.5", "2021-03-04 05:06:07 +0000 UTC", "1m30s", "10.0.0.1", "any"} {
+		if err := SetCellText(v.Field(i), text); err != nil {
+			t.Errorf("Error %v", err)
+		}
+	}
+
+	when := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	if got.S != "s" || !*got.B || got.I != -8 || got.U != 8 || got.F != 1.5 || !got.T.Equal(when) ||
+		got.D != 90*time.Second || !got.IP.Equal(net.IPv4(10, 0, 0, 1)) || got.A != "any" {
+		t.Errorf("got %v", got)
+	}
+
+	if err := SetCellText(v.Field(5), "2021-03-04"); err != nil || !got.T.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("time %v %v", got.T, err)
+	}
+}
+
+func TestSetCellTextError(t *testing.T) {
+	var got cellTextData
+	v := reflect.ValueOf(&got).Elem()
+
+	// Every field but the string S
+	for i, text := range []string{"yes", "200", "-1", "x", "today", "1 day", "ip"} {
+		field := v.Field(i + 1)
+
+		err := SetCellText(field, text)
+		if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorCellText, text, indirect(field).Type()) {
+			t.Errorf("field %d err %v", i+1, err)
+		}
+	}
+}
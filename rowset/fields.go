@@ -0,0 +1,212 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rowset provides reflection helpers that operate on the rows of a data set,
+// resolving fields by name and ordering rows prior to formatting.
+package rowset
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	tabularTagName = "tabular"
+	jsonTagName    = "json"
+	csvTagName     = "csv"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldNames returns the names a struct field can be referred to by,
+// the tabular tag name, json tag name, csv tag name and Go field name.
+// Unexported fields have no names.
+func fieldNames(field reflect.StructField) []string {
+	if field.Name == "" || !unicode.IsUpper([]rune(field.Name)[0]) {
+		return nil
+	}
+
+	names := make([]string, 0, 4)
+
+	for _, tagType := range []string{tabularTagName, jsonTagName, csvTagName} {
+		name := strings.Trim(strings.SplitN(field.Tag.Get(tagType), ",", 2)[0], " ")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return append(names, field.Name)
+}
+
+func matchesName(field reflect.StructField, name string) bool {
+	for _, n := range fieldNames(field) {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLeaf returns true if values of the type are not flattened into their fields.
+func isLeaf(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.Struct || t == timeType
+}
+
+// FieldIndex resolves a field name to the index sequence of the field within the struct type t.
+// Names are matched, ignoring case, against the tabular tag, json tag, csv tag or Go name of a field.
+// Nested structs are flattened so the fields of a nested struct can be referenced directly
+// or using a dotted path, i.e. "Embedded.IntTwo".
+func FieldIndex(t reflect.Type, name string) ([]int, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || name == "" {
+		return nil, false
+	}
+
+	if strings.Contains(name, ".") {
+		return pathIndex(t, strings.Split(name, "."))
+	}
+
+	return flattenedIndex(t, name)
+}
+
+// flattenedIndex searches the struct, depth first, for a leaf field with the name.
+func flattenedIndex(t reflect.Type, name string) ([]int, bool) {
+	n := t.NumField()
+	for i := 0; i < n; i++ {
+		field := t.Field(i)
+		if len(fieldNames(field)) == 0 {
+			continue
+		}
+
+		if isLeaf(field.Type) {
+			if matchesName(field, name) {
+				return []int{i}, true
+			}
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if index, ok := flattenedIndex(ft, name); ok {
+			return append([]int{i}, index...), true
+		}
+	}
+
+	return nil, false
+}
+
+// pathIndex follows a dotted path through nested structs.
+func pathIndex(t reflect.Type, path []string) ([]int, bool) {
+	index := make([]int, 0, len(path))
+
+	for i, name := range path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		field, ok := directField(t, strings.Trim(name, " "))
+		if !ok {
+			return nil, false
+		}
+
+		index = append(index, field.Index...)
+		t = field.Type
+
+		// Intermediate path elements must be structs
+		if i < len(path)-1 && isLeaf(t) {
+			return nil, false
+		}
+	}
+
+	return index, true
+}
+
+func directField(t reflect.Type, name string) (reflect.StructField, bool) {
+	n := t.NumField()
+	for i := 0; i < n; i++ {
+		if field := t.Field(i); matchesName(field, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// FieldByIndex returns the nested field of v with the index sequence, following pointers.
+// ok is false if a nil pointer is found along the path.
+func FieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v, true
+}
+
+// IsKeyed returns true if t is a map with string keys, the keys of which are used as field names.
+func IsKeyed(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// MapValue returns the value of the key name in a map with string keys, falling back to a case insensitive match.
+// ok is false if the map has no matching key.
+func MapValue(item reflect.Value, name string) (reflect.Value, bool) {
+	key := reflect.ValueOf(name).Convert(item.Type().Key())
+	if v := item.MapIndex(key); v.IsValid() {
+		return v, true
+	}
+
+	iter := item.MapRange()
+	for iter.Next() {
+		if strings.EqualFold(iter.Key().String(), name) {
+			return iter.Value(), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// SortedKeys returns the keys of a map in sorted order.
+func SortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return compare(keys[i], keys[j]) < 0
+	})
+
+	return keys
+}
@@ -0,0 +1,92 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"testing"
+)
+
+type innerRow struct {
+	IntTwo int `tabular:"Two"`
+	hidden int //nolint:structcheck,unused
+}
+
+type testRow struct {
+	Name     string
+	Size     int64  `json:"bytes"`
+	Status   string `tabular:"State"`
+	Embedded innerRow
+	Ptr      *innerRow
+}
+
+func TestFieldIndex(t *testing.T) {
+	rt := reflect.TypeOf(testRow{})
+
+	cases := map[string][]int{
+		"Name":            {0},
+		"name":            {0},
+		"bytes":           {1},
+		"Size":            {1},
+		"state":           {2},
+		"Status":          {2},
+		"Two":             {3, 0},
+		"IntTwo":          {3, 0},
+		"Embedded.Two":    {3, 0},
+		"ptr.IntTwo":      {4, 0},
+		"Embedded.hidden": nil,
+		"hidden":          nil,
+		"Embedded":        nil,
+		"Name.Other":      nil,
+		"Unknown":         nil,
+	}
+
+	for name, expected := range cases {
+		index, ok := FieldIndex(rt, name)
+		if expected == nil {
+			if ok {
+				t.Errorf("FieldIndex(%v) found %v", name, index)
+			}
+			continue
+		}
+
+		if !ok || !reflect.DeepEqual(index, expected) {
+			t.Errorf("FieldIndex(%v) = %v %v, expected %v", name, index, ok, expected)
+		}
+	}
+}
+
+func TestFieldIndexNotStruct(t *testing.T) {
+	if _, ok := FieldIndex(reflect.TypeOf(10), "Name"); ok {
+		t.Error("Found field in int")
+	}
+}
+
+func TestFieldByIndex(t *testing.T) {
+	row := &testRow{Name: "one", Ptr: &innerRow{IntTwo: 2}}
+
+	v, ok := FieldByIndex(reflect.ValueOf(row), []int{4, 0})
+	if !ok || v.Int() != 2 {
+		t.Errorf("unexpected %v %v", v, ok)
+	}
+
+	row.Ptr = nil
+
+	if _, ok := FieldByIndex(reflect.ValueOf(row), []int{4, 0}); ok {
+		t.Error("Followed nil pointer")
+	}
+}
@@ -0,0 +1,654 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// Filter is a parsed filter expression used to select rows, i.e. Status == "failed" && Retries > 2.
+// Field names are resolved using FieldIndex, or as keys for map rows.
+// Comparison operators are ==, !=, <, <=, >, >= and =~ (regular expression match),
+// expressions can be combined with &&, || and ! and grouped with parentheses.
+// Literals can be quoted strings, numbers, true, false or nil.
+type Filter struct {
+	expression string
+	root       node
+}
+
+// ParseFilter parses a filter expression.
+func ParseFilter(expression string) (*Filter, error) {
+	p := &parser{lexer: newLexer(expression)}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.kind != tokenEOF {
+		return nil, p.syntaxError()
+	}
+
+	return &Filter{expression: expression, root: root}, nil
+}
+
+// String returns the filter expression.
+func (f *Filter) String() string {
+	return f.expression
+}
+
+// Match returns true if the item matches the filter.
+func (f *Filter) Match(item interface{}) (bool, error) {
+	return f.match(reflect.ValueOf(item), make(map[reflect.Type]map[string][]int))
+}
+
+func (f *Filter) match(item reflect.Value, indexes map[reflect.Type]map[string][]int) (bool, error) {
+	v, err := f.root.eval(&row{item: indirect(item), indexes: indexes})
+	if err != nil {
+		return false, err
+	}
+
+	return asBool(v)
+}
+
+// Apply returns the items of a slice or array that match the filter as a new slice.
+// Pointers to slices or arrays are followed, any other data is returned unfiltered.
+func (f *Filter) Apply(data interface{}) (interface{}, error) {
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Ptr {
+		value = reflect.Indirect(value)
+	}
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return data, nil
+	}
+
+	indexes := make(map[reflect.Type]map[string][]int)
+	n := value.Len()
+	filtered := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), 0, n)
+
+	for i := 0; i < n; i++ {
+		item := value.Index(i)
+
+		ok, err := f.match(item, indexes)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			filtered = reflect.Append(filtered, item)
+		}
+	}
+
+	return filtered.Interface(), nil
+}
+
+// row is the item being evaluated, indexes caches resolved field indexes by type.
+type row struct {
+	item    reflect.Value
+	indexes map[reflect.Type]map[string][]int
+}
+
+func (r *row) field(name string) (interface{}, error) {
+	item := r.item
+
+	if !item.IsValid() {
+		return nil, nil
+	}
+
+	if IsKeyed(item.Type()) {
+		v, _ := MapValue(item, name)
+		return normalize(v), nil
+	}
+
+	names, ok := r.indexes[item.Type()]
+	if !ok {
+		names = make(map[string][]int)
+		r.indexes[item.Type()] = names
+	}
+
+	index, ok := names[name]
+	if !ok {
+		if index, ok = filterFieldIndex(item.Type(), name); !ok {
+			return nil, lpax.Errorf(langpack.ErrorUnknownField, name)
+		}
+		names[name] = index
+	}
+
+	v, ok := FieldByIndex(item, index)
+	if !ok {
+		return nil, nil
+	}
+
+	return normalize(v), nil
+}
+
+// filterFieldIndex resolves a field using FieldIndex, falling back to direct struct fields
+// so nested structs and pointers can be compared with nil.
+func filterFieldIndex(t reflect.Type, name string) ([]int, bool) {
+	if index, ok := FieldIndex(t, name); ok {
+		return index, true
+	}
+
+	if t.Kind() != reflect.Struct || strings.Contains(name, ".") {
+		return nil, false
+	}
+
+	return pathIndex(t, []string{name})
+}
+
+// normalize converts a value into one of nil, bool, float64, string, time.Time or
+// failing that its string representation.
+func normalize(v reflect.Value) interface{} {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	}
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time)
+	}
+
+	return toString(v)
+}
+
+func asBool(v interface{}) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, lpax.Errorf(langpack.ErrorFilterNotBoolean, v)
+	}
+	return b, nil
+}
+
+// node is an element of the parsed expression.
+type node interface {
+	eval(r *row) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(r *row) (interface{}, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	name string
+}
+
+func (n *fieldNode) eval(r *row) (interface{}, error) {
+	return r.field(n.name)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(r *row) (interface{}, error) {
+	v, err := n.operand.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := asBool(v)
+	return !b, err
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicalNode) eval(r *row) (interface{}, error) {
+	v, err := n.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	left, err := asBool(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short circuit
+	if left != n.and {
+		return left, nil
+	}
+
+	if v, err = n.right.eval(r); err != nil {
+		return nil, err
+	}
+
+	return asBool(v)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+	pattern     *regexp.Regexp
+}
+
+func (n *compareNode) eval(r *row) (interface{}, error) {
+	a, err := n.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.pattern != nil {
+		return a != nil && n.pattern.MatchString(fmt.Sprint(a)), nil
+	}
+
+	b, err := n.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if a == nil || b == nil {
+		switch n.op {
+		case "==":
+			return a == nil && b == nil, nil
+		case "!=":
+			return !(a == nil && b == nil), nil
+		default:
+			return false, nil
+		}
+	}
+
+	c := compareNormalized(a, b)
+
+	switch n.op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// timeLayouts are the layouts used to compare time fields with string literals.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareNormalized compares two normalized values, values of different types are compared as strings.
+func compareNormalized(a, b interface{}) int {
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return compareOrdered(av < bv, av > bv)
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			return compareOrdered(!av && bv, av && !bv)
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return compareOrdered(av.Before(bv), av.After(bv))
+		}
+		if bs, ok := b.(string); ok {
+			if bv, ok := parseTime(bs); ok {
+				return compareOrdered(av.Before(bv), av.After(bv))
+			}
+		}
+	case string:
+		if _, ok := b.(time.Time); ok {
+			return -compareNormalized(b, a)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func newLexer(input string) *lexer {
+	return &lexer{input: input}
+}
+
+// operators in matching order, longest first.
+var operators = []string{"==", "!=", "<=", ">=", "=~", "&&", "||", "<", ">", "!", "(", ")"}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+
+	start := l.pos
+	if start >= len(l.input) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	rest := l.input[start:]
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.pos += len(op)
+			return token{kind: tokenOperator, text: op, pos: start}, nil
+		}
+	}
+
+	c := rest[0]
+	switch {
+	case c == '"' || c == '\'':
+		return l.lexString(c)
+
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.lexNumber()
+
+	case c == '_' || unicode.IsLetter(rune(c)) || c >= 0x80:
+		for l.pos < len(l.input) {
+			r := rune(l.input[l.pos])
+			if r != '_' && r != '.' && r < 0x80 && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: tokenIdent, text: l.input[start:l.pos], pos: start}, nil
+
+	default:
+		return token{}, lpax.Errorf(langpack.ErrorFilterSyntax, start+1, l.input)
+	}
+}
+
+func (l *lexer) lexString(quote byte) (token, error) {
+	start := l.pos
+
+	for i := start + 1; i < len(l.input); i++ {
+		switch l.input[i] {
+		case '\\':
+			i++
+		case quote:
+			l.pos = i + 1
+			text := l.input[start:l.pos]
+
+			// Single quoted strings are requoted as double quoted strings
+			if quote == '\'' {
+				text = "\"" + strings.NewReplacer("\\'", "'", "\"", "\\\"").Replace(text[1:len(text)-1]) + "\""
+			}
+
+			s, err := strconv.Unquote(text)
+			if err != nil {
+				return token{}, lpax.Errorf(langpack.ErrorFilterSyntax, start+1, l.input)
+			}
+
+			return token{kind: tokenString, text: text, value: s, pos: start}, nil
+		}
+	}
+
+	return token{}, lpax.Errorf(langpack.ErrorFilterSyntax, start+1, l.input)
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	l.pos++
+
+	for l.pos < len(l.input) && strings.IndexByte("0123456789.eE+-_", l.input[l.pos]) >= 0 {
+		// Signs are only part of an exponent
+		if c := l.input[l.pos]; (c == '+' || c == '-') && !strings.ContainsAny(l.input[l.pos-1:l.pos], "eE") {
+			break
+		}
+		l.pos++
+	}
+
+	text := l.input[start:l.pos]
+	f, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	if err != nil {
+		return token{}, lpax.Errorf(langpack.ErrorFilterSyntax, start+1, l.input)
+	}
+
+	return token{kind: tokenNumber, text: text, value: f, pos: start}, nil
+}
+
+// parser is a recursive descent parser of filter expressions.
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *parser) syntaxError() error {
+	return lpax.Errorf(langpack.ErrorFilterSyntax, p.token.pos+1, p.lexer.input)
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	if p.token.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if p.token.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("||") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("&&") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch {
+	case p.isOperator("!"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{operand: operand}, nil
+
+	case p.isOperator("("):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isOperator(")") {
+			return nil, p.syntaxError()
+		}
+
+		return n, p.advance()
+
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isOperator("==", "!=", "<", "<=", ">", ">=", "=~") {
+		return left, nil
+	}
+
+	op := p.token.text
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	pos := p.token.pos
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &compareNode{op: op, left: left, right: right}
+
+	if op == "=~" {
+		lit, ok := right.(*literalNode)
+		pattern := ""
+		if ok {
+			pattern, ok = lit.value.(string)
+		}
+		if !ok {
+			return nil, lpax.Errorf(langpack.ErrorFilterSyntax, pos+1, p.lexer.input)
+		}
+
+		if cmp.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return cmp, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.token
+
+	var n node
+
+	switch t.kind {
+	case tokenString, tokenNumber:
+		n = &literalNode{value: t.value}
+
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			n = &literalNode{value: true}
+		case "false":
+			n = &literalNode{value: false}
+		case "nil", "null":
+			n = &literalNode{}
+		default:
+			n = &fieldNode{name: t.text}
+		}
+
+	default:
+		return nil, p.syntaxError()
+	}
+
+	return n, p.advance()
+}
@@ -0,0 +1,257 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+)
+
+func filterRows() []testRow {
+	return []testRow{
+		{Name: "a", Status: "failed", Size: 3, Embedded: innerRow{IntTwo: 1}},
+		{Name: "b", Status: "ok", Size: 5},
+		{Name: "c", Status: "failed", Size: 1, Ptr: &innerRow{IntTwo: 7}},
+		{Name: "d", Status: "failed", Size: 10},
+	}
+}
+
+func filterNames(t *testing.T, expression string, data interface{}) []string {
+	t.Helper()
+
+	filter, err := ParseFilter(expression)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	filtered, err := filter.Apply(data)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	names := []string{}
+	for _, r := range filtered.([]testRow) {
+		names = append(names, r.Name)
+	}
+
+	return names
+}
+
+func TestFilterApply(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   []string
+	}{
+		{`Status == "failed" && Size > 2`, []string{"a", "d"}},
+		{`state == 'ok' || bytes >= 10`, []string{"b", "d"}},
+		{`!(Status == "failed")`, []string{"b"}},
+		{`Status != "failed" || Name =~ "^[cd]$"`, []string{"b", "c", "d"}},
+		{`Embedded.Two == 1 || Two == 7`, []string{"a"}},
+		{`Ptr.IntTwo == 7`, []string{"c"}},
+		{`Ptr == nil && Size < 5`, []string{"a"}},
+		{`Size <= 1.5e0`, []string{"c"}},
+	}
+
+	for _, test := range tests {
+		names := filterNames(t, test.expression, filterRows())
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%s: unexpected rows %v", test.expression, names)
+		}
+	}
+}
+
+func TestFilterApplyArrayPointer(t *testing.T) {
+	rows := [2]testRow{{Name: "a", Size: 1}, {Name: "b", Size: 2}}
+
+	names := filterNames(t, "Size == 2", &rows)
+	if !reflect.DeepEqual(names, []string{"b"}) {
+		t.Errorf("unexpected rows %v", names)
+	}
+}
+
+func TestFilterApplyNotSlice(t *testing.T) {
+	filter, err := ParseFilter("Size == 2")
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	row := testRow{Name: "a"}
+
+	filtered, err := filter.Apply(row)
+	if err != nil || filtered != row {
+		t.Errorf("unexpected result %v %v", filtered, err)
+	}
+}
+
+func TestFilterMaps(t *testing.T) {
+	filter, err := ParseFilter(`status == "failed" && retries > 2`)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	rows := []map[string]interface{}{
+		{"Status": "failed", "Retries": 3.0},
+		{"Status": "failed", "Retries": 1},
+		{"Status": "ok"},
+	}
+
+	filtered, err := filter.Apply(rows)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	if len(filtered.([]map[string]interface{})) != 1 {
+		t.Errorf("unexpected rows %v", filtered)
+	}
+}
+
+func TestFilterTime(t *testing.T) {
+	filter, err := ParseFilter(`When >= "2021-06-01" && Count == nil`)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	for _, test := range []struct {
+		row      timedRow
+		expected bool
+	}{
+		{timedRow{When: time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)}, true},
+		{timedRow{When: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)}, false},
+	} {
+		ok, err := filter.Match(test.row)
+		if err != nil || ok != test.expected {
+			t.Errorf("%v: unexpected match %v %v", test.row.When, ok, err)
+		}
+	}
+}
+
+func TestFilterUnknownField(t *testing.T) {
+	filter, err := ParseFilter(`Missing == 1`)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	_, err = filter.Apply(filterRows())
+
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorUnknownField, "Missing") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestFilterNotBoolean(t *testing.T) {
+	filter, err := ParseFilter(`Name && Size > 1`)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	if _, err = filter.Match(testRow{Name: "a"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`Status ==`,
+		`(Status == "ok"`,
+		`Status == "ok" Size`,
+		`Status = "ok"`,
+		`Status == "ok`,
+		`Name =~ Status`,
+		`Size > 1..2`,
+	} {
+		if _, err := ParseFilter(expression); err == nil {
+			t.Errorf("%s: expected error", expression)
+		}
+	}
+}
+
+type filterTestFormatter struct {
+	data []interface{}
+}
+
+func (f *filterTestFormatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	f.data = data
+	return nil
+}
+
+func (f *filterTestFormatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	return f, nil
+}
+
+func (f *filterTestFormatter) Write(row interface{}) error {
+	f.data = append(f.data, row)
+	return nil
+}
+
+func (f *filterTestFormatter) Close() error {
+	return nil
+}
+
+func TestFilterFormatter(t *testing.T) {
+	filter, err := ParseFilter(`Status == "failed"`)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	tf := &filterTestFormatter{}
+	fmt := NewFilterFormatter(tf, filter)
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, nil, filterRows(), "scalar"); err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	if len(tf.data) != 2 || len(tf.data[0].([]testRow)) != 3 || tf.data[1] != "scalar" {
+		t.Errorf("unexpected data %v", tf.data)
+	}
+}
+
+func TestFilterFormatterStream(t *testing.T) {
+	filter, err := ParseFilter(`Status == "failed"`)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	tf := &filterTestFormatter{}
+	fmt, ok := NewFilterFormatter(tf, filter).(yaff.StreamFormatter)
+	if !ok {
+		t.Fatal("not a stream formatter")
+	}
+
+	stream, err := fmt.NewStream(&bytes.Buffer{}, nil)
+	if err != nil {
+		t.Fatalf("Error %v", err)
+	}
+
+	for _, r := range filterRows() {
+		if err := stream.Write(r); err != nil {
+			t.Fatalf("Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil || len(tf.data) != 3 {
+		t.Errorf("unexpected data %v %v", tf.data, err)
+	}
+}
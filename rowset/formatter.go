@@ -0,0 +1,106 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"context"
+	"io"
+	"reflect"
+
+	"github.com/nehemming/yaff"
+)
+
+// filterFormatter filters data before passing it to the formatter.
+type filterFormatter struct {
+	formatter yaff.ContextFormatter
+	filter    *Filter
+}
+
+// filterStreamFormatter additionally filters streamed rows.
+type filterStreamFormatter struct {
+	*filterFormatter
+	streamFormatter yaff.StreamFormatter
+}
+
+// filterStream writes rows matching the filter to the underlying stream.
+type filterStream struct {
+	stream  yaff.Stream
+	filter  *Filter
+	indexes map[reflect.Type]map[string][]int
+}
+
+// NewFilterFormatter returns a formatter that removes the elements of slices and arrays that do not
+// match filter before formatting them with formatter.
+// If formatter supports streaming the returned formatter filters streamed rows too.
+func NewFilterFormatter(formatter yaff.Formatter, filter *Filter) yaff.Formatter {
+	ff := &filterFormatter{formatter: yaff.WithContext(formatter), filter: filter}
+
+	if sf, ok := formatter.(yaff.StreamFormatter); ok {
+		return &filterStreamFormatter{filterFormatter: ff, streamFormatter: sf}
+	}
+
+	return ff
+}
+
+func (ff *filterFormatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	return ff.FormatContext(context.Background(), writer, options, data...)
+}
+
+func (ff *filterFormatter) FormatContext(ctx context.Context, writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	filtered := make([]interface{}, len(data))
+
+	for i, d := range data {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		f, err := ff.filter.Apply(d)
+		if err != nil {
+			return err
+		}
+
+		filtered[i] = f
+	}
+
+	return ff.formatter.FormatContext(ctx, writer, options, filtered...)
+}
+
+func (fsf *filterStreamFormatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	stream, err := fsf.streamFormatter.NewStream(writer, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filterStream{
+		stream:  stream,
+		filter:  fsf.filter,
+		indexes: make(map[reflect.Type]map[string][]int),
+	}, nil
+}
+
+func (fs *filterStream) Write(row interface{}) error {
+	ok, err := fs.filter.match(reflect.ValueOf(row), fs.indexes)
+	if err != nil || !ok {
+		return err
+	}
+
+	return fs.stream.Write(row)
+}
+
+func (fs *filterStream) Close() error {
+	return fs.stream.Close()
+}
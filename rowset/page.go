@@ -0,0 +1,99 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import "reflect"
+
+// Page selects a window of the rows of a slice or array.
+type Page struct {
+	// Offset is the number of rows skipped before the page starts.
+	Offset int
+	// Limit is the maximum number of rows in the page, 0 for no limit.
+	Limit int
+}
+
+// Envelope wraps a page of items with the total number of rows and the offset of the page.
+type Envelope struct {
+	Total  int         `json:"total" yaml:"total"`
+	Offset int         `json:"offset" yaml:"offset"`
+	Items  interface{} `json:"items" yaml:"items"`
+}
+
+// IsSet returns true if the page skips or limits rows.
+func (p Page) IsSet() bool {
+	return p.Offset > 0 || p.Limit > 0
+}
+
+// Window returns the start and end indexes of the page within n rows.
+func (p Page) Window(n int) (start, end int) {
+	start, end = p.Offset, n
+
+	if start < 0 {
+		start = 0
+	}
+
+	if start > n {
+		start = n
+	}
+
+	if p.Limit > 0 && start+p.Limit < end {
+		end = start + p.Limit
+	}
+
+	return start, end
+}
+
+// Includes returns true if the row with the zero based index i is within the page.
+func (p Page) Includes(i int) bool {
+	return i >= p.Offset && (p.Limit <= 0 || i < p.Offset+p.Limit)
+}
+
+// Apply returns the page of rows of a slice or array as a new slice along with the total number of rows.
+// Other data is returned as is with a total of 1.
+func (p Page) Apply(data interface{}) (interface{}, int) {
+	value := reflect.ValueOf(data)
+	if value.Kind() == reflect.Ptr {
+		value = reflect.Indirect(value)
+	}
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return data, 1
+	}
+
+	n := value.Len()
+	if !p.IsSet() {
+		return data, n
+	}
+
+	start, end := p.Window(n)
+	page := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), 0, end-start)
+
+	for i := start; i < end; i++ {
+		page = reflect.Append(page, value.Index(i))
+	}
+
+	return page.Interface(), n
+}
+
+// Envelope returns the page of rows of data wrapped in an envelope.
+func (p Page) Envelope(data interface{}) Envelope {
+	items, total := p.Apply(data)
+
+	start, _ := p.Window(total)
+
+	return Envelope{Total: total, Offset: start, Items: items}
+}
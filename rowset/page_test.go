@@ -0,0 +1,75 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPageWindow(t *testing.T) {
+	tests := []struct {
+		page       Page
+		start, end int
+	}{
+		{Page{}, 0, 10},
+		{Page{Limit: 3}, 0, 3},
+		{Page{Offset: 8, Limit: 5}, 8, 10},
+		{Page{Offset: 12}, 10, 10},
+		{Page{Offset: -1, Limit: 20}, 0, 10},
+	}
+
+	for _, test := range tests {
+		start, end := test.page.Window(10)
+		if start != test.start || end != test.end {
+			t.Errorf("%+v window %d-%d, expected %d-%d", test.page, start, end, test.start, test.end)
+		}
+	}
+}
+
+func TestPageApply(t *testing.T) {
+	rows := [4]int{1, 2, 3, 4}
+
+	page, total := Page{Offset: 1, Limit: 2}.Apply(&rows)
+	if total != 4 || !reflect.DeepEqual(page, []int{2, 3}) {
+		t.Errorf("unexpected page %v of %d", page, total)
+	}
+
+	page, total = Page{Limit: 2}.Apply("value")
+	if total != 1 || page != "value" {
+		t.Errorf("unexpected page %v of %d", page, total)
+	}
+}
+
+func TestPageEnvelope(t *testing.T) {
+	envelope := Page{Offset: 2, Limit: 1}.Envelope([]string{"a", "b", "c"})
+
+	expected := Envelope{Total: 3, Offset: 2, Items: []string{"c"}}
+	if !reflect.DeepEqual(envelope, expected) {
+		t.Errorf("unexpected envelope %+v", envelope)
+	}
+}
+
+func TestPageIncludes(t *testing.T) {
+	page := Page{Offset: 1, Limit: 2}
+
+	for i, expected := range []bool{false, true, true, false} {
+		if page.Includes(i) != expected {
+			t.Errorf("row %d included %v", i, !expected)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// SortKey is a column rows are sorted by.
+type SortKey struct {
+	// Column is the name of the field, resolved using FieldIndex.
+	Column string
+
+	// Descending reverses the sort order of the column.
+	Descending bool
+}
+
+// ParseSortKeys parses a comma separated sort specification.
+// Columns prefixed with '-' or followed by " desc" sort in descending order, i.e. "Status,-Size,Age desc".
+func ParseSortKeys(spec string) []SortKey {
+	keys := make([]SortKey, 0, 2)
+
+	for _, s := range strings.Split(spec, ",") {
+		fields := strings.Fields(s)
+		if len(fields) == 0 {
+			continue
+		}
+
+		key := SortKey{Column: fields[0]}
+
+		if strings.HasPrefix(key.Column, "-") {
+			key.Column = key.Column[1:]
+			key.Descending = true
+		}
+
+		if len(fields) > 1 && strings.EqualFold(fields[1], "desc") {
+			key.Descending = true
+		}
+
+		if key.Column != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// SortIndices returns the indices of the items of a slice or array in sorted order.
+// Fields are compared by their kind, numbers numerically, times chronologically and
+// everything else lexically.  Items that are not structs are compared by their own value.
+// The sort is stable so items with equal keys keep their original order.
+func SortIndices(value reflect.Value, keys []SortKey) ([]int, error) {
+	k := value.Kind()
+	if k != reflect.Array && k != reflect.Slice {
+		return nil, lpax.Errorf(langpack.ErrorNotSortable, value.Type())
+	}
+
+	n := value.Len()
+	order := make([]int, n)
+	values := make([][]reflect.Value, n)
+	indexes := make(map[reflect.Type][][]int)
+
+	for i := 0; i < n; i++ {
+		order[i] = i
+
+		itemValues, err := sortValues(value.Index(i), keys, indexes)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = itemValues
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := values[order[i]], values[order[j]]
+
+		for k, key := range keys {
+			c := compare(a[k], b[k])
+			if c == 0 {
+				continue
+			}
+
+			if key.Descending {
+				return c > 0
+			}
+			return c < 0
+		}
+
+		return false
+	})
+
+	return order, nil
+}
+
+// sortValues returns the value of each sort key for an item, indexes caches the field index
+// of each key by item type.
+func sortValues(item reflect.Value, keys []SortKey, indexes map[reflect.Type][][]int) ([]reflect.Value, error) {
+	item = indirect(item)
+
+	values := make([]reflect.Value, len(keys))
+
+	// Map keys are used as field names
+	if item.IsValid() && IsKeyed(item.Type()) {
+		for k, key := range keys {
+			v, _ := MapValue(item, key.Column)
+			values[k] = indirect(v)
+		}
+		return values, nil
+	}
+
+	if !item.IsValid() || isLeaf(item.Type()) {
+		for k := range keys {
+			values[k] = item
+		}
+		return values, nil
+	}
+
+	keyIndexes, ok := indexes[item.Type()]
+	if !ok {
+		keyIndexes = make([][]int, len(keys))
+		for k, key := range keys {
+			index, ok := FieldIndex(item.Type(), key.Column)
+			if !ok {
+				return nil, lpax.Errorf(langpack.ErrorUnknownField, key.Column)
+			}
+			keyIndexes[k] = index
+		}
+		indexes[item.Type()] = keyIndexes
+	}
+
+	for k, index := range keyIndexes {
+		if v, ok := FieldByIndex(item, index); ok {
+			values[k] = indirect(v)
+		}
+	}
+
+	return values, nil
+}
+
+// indirect follows pointers and interfaces to the underlying value.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// compare returns -1, 0 or 1 comparing a with b. Invalid (nil) values sort first.
+func compare(a, b reflect.Value) int {
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return 0
+	case !a.IsValid():
+		return -1
+	case !b.IsValid():
+		return 1
+	}
+
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+
+		case reflect.Float32, reflect.Float64:
+			return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+
+		case reflect.Bool:
+			return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+
+		case reflect.String:
+			return strings.Compare(a.String(), b.String())
+
+		case reflect.Struct:
+			if a.Type() == timeType && b.Type() == timeType {
+				ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+				return compareOrdered(ta.Before(tb), ta.After(tb))
+			}
+		}
+	}
+
+	return strings.Compare(toString(a), toString(b))
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toString(v reflect.Value) string {
+	if v.CanInterface() {
+		return fmt.Sprint(v.Interface())
+	}
+	return v.String()
+}
@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rowset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSortKeys(t *testing.T) {
+	keys := ParseSortKeys(" Status, -Size,Age desc, Name asc,,-")
+
+	expected := []SortKey{
+		{Column: "Status"},
+		{Column: "Size", Descending: true},
+		{Column: "Age", Descending: true},
+		{Column: "Name"},
+	}
+
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
+
+func TestSortIndicesMultipleKeys(t *testing.T) {
+	rows := []testRow{
+		{Name: "b", Status: "ok", Size: 10},
+		{Name: "a", Status: "failed", Size: 9},
+		{Name: "c", Status: "ok", Size: 100},
+		{Name: "d", Status: "failed", Size: 20},
+	}
+
+	order, err := SortIndices(reflect.ValueOf(rows), ParseSortKeys("state,-bytes"))
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	if !reflect.DeepEqual(order, []int{3, 1, 2, 0}) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestSortIndicesNumericNotLexical(t *testing.T) {
+	rows := []*testRow{{Size: 100}, {Size: 9}, nil, {Size: 20}}
+
+	order, err := SortIndices(reflect.ValueOf(rows), ParseSortKeys("Size"))
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	if !reflect.DeepEqual(order, []int{2, 1, 3, 0}) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+type timedRow struct {
+	When  time.Time
+	Count *int
+}
+
+func TestSortIndicesTimeAndPointers(t *testing.T) {
+	now := time.Now()
+	one, two := 1, 2
+
+	rows := [3]timedRow{
+		{When: now, Count: &two},
+		{When: now.Add(-time.Hour), Count: nil},
+		{When: now.Add(time.Hour), Count: &one},
+	}
+
+	order, err := SortIndices(reflect.ValueOf(rows), ParseSortKeys("-When"))
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	if !reflect.DeepEqual(order, []int{2, 0, 1}) {
+		t.Errorf("unexpected time order %v", order)
+	}
+
+	order, _ = SortIndices(reflect.ValueOf(rows), ParseSortKeys("Count"))
+
+	if !reflect.DeepEqual(order, []int{1, 2, 0}) {
+		t.Errorf("unexpected pointer order %v", order)
+	}
+}
+
+func TestSortIndicesScalars(t *testing.T) {
+	order, err := SortIndices(reflect.ValueOf([]float64{2.5, -1, 10}), ParseSortKeys("-Output"))
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	if !reflect.DeepEqual(order, []int{2, 0, 1}) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestSortIndicesUnknownField(t *testing.T) {
+	if _, err := SortIndices(reflect.ValueOf([]testRow{{}}), ParseSortKeys("Missing")); err == nil {
+		t.Error("No unknown field error")
+	}
+}
+
+func TestSortIndicesNotSortable(t *testing.T) {
+	if _, err := SortIndices(reflect.ValueOf(testRow{}), ParseSortKeys("Name")); err == nil {
+		t.Error("No sort error")
+	}
+}
+
+func TestSortIndicesMaps(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"Name": "b", "size": 10},
+		{"Name": "a"},
+		{"Name": "c", "size": 5},
+	}
+
+	order, err := SortIndices(reflect.ValueOf(rows), ParseSortKeys("Size"))
+	if err != nil {
+		t.Error("err:", err)
+	}
+
+	if !reflect.DeepEqual(order, []int{1, 2, 0}) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	keys := SortedKeys(reflect.ValueOf(map[int]bool{10: true, 2: true, 1: false}))
+
+	if len(keys) != 3 || keys[0].Int() != 1 || keys[2].Int() != 10 {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package templateformatter
 
 import (
+	"context"
 	"io"
 
 	tt "text/template"
@@ -50,6 +51,10 @@ func NewOptions() Options {
 }
 
 func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	return f.FormatContext(context.Background(), writer, options, data...)
+}
+
+func (f *formatter) FormatContext(ctx context.Context, writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
 	if options == nil {
 		options = NewOptions()
 	}
@@ -60,21 +65,24 @@ func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ..
 		return lpax.Errorf(langpack.ErrorInvalidOptionType, options, Template)
 	}
 
+	// Template execution fails on its next write once the context is done
+	writer = yaff.NewContextWriter(ctx, writer)
+
 	if templateOptions.Template != "" {
-		return reportTextTemplate(writer, templateOptions.Template, data)
+		return reportTextTemplate(ctx, writer, templateOptions.Template, data)
 	} else if templateOptions.TemplateFile != "" {
 		buf, err := fsio.ReadFileFromPath(templateOptions.TemplateFile)
 		if err != nil {
 			return err
 		}
-		return reportTextTemplate(writer, string(buf), data)
+		return reportTextTemplate(ctx, writer, string(buf), data)
 	}
 
 	// No format specified
 	return lpax.Errorf(langpack.ErrorNoTemplateDefinition, Template)
 }
 
-func reportTextTemplate(writer io.Writer, template string, data []interface{}) error {
+func reportTextTemplate(ctx context.Context, writer io.Writer, template string, data []interface{}) error {
 	// prep template
 	t, err := tt.New("main").Parse(template)
 	if err != nil {
@@ -84,6 +92,10 @@ func reportTextTemplate(writer io.Writer, template string, data []interface{}) e
 	// run the template per input
 
 	for _, d := range data {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
 		err = t.Execute(writer, d)
 		if err != nil {
 			return err
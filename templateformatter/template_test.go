@@ -18,6 +18,8 @@ package templateformatter
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/nehemming/testsupport"
@@ -126,3 +128,26 @@ func TestNewFormatterLoadFromFile(t *testing.T) {
 
 	testsupport.CompareStrings(t, expected, got)
 }
+
+func TestFormatContextCancelled(t *testing.T) {
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+
+	err = f.(yaff.ContextFormatter).FormatContext(ctx, &buf, Options{Template: "{{ .S }}"},
+		&testData{S: "Hello"}, &testData{S: "Again"})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
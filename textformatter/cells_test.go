@@ -0,0 +1,138 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+)
+
+type amount struct {
+	pence int
+}
+
+func (a *amount) FormatCell() (string, yaff.Alignment) {
+	return "£" + time.Duration(a.pence).String(), yaff.AlignRight
+}
+
+type state int
+
+func (s state) String() string {
+	return []string{"stopped", "running"}[s]
+}
+
+type cellData struct {
+	Host  string
+	IP    net.IP
+	State state
+	Cost  amount
+	Spare *amount
+}
+
+func TestCellFormatting(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, NewOptions(), []cellData{
+		{Host: "web", IP: net.ParseIP("10.0.0.1"), State: 1, Cost: amount{pence: 5}},
+		{Host: "database", IP: net.ParseIP("10.0.0.22"), Cost: amount{pence: 1000}, Spare: &amount{pence: 1}},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `Host     IP        State   Cost Spare
+web      10.0.0.1  running £5ns      
+database 10.0.0.22 stopped £1µs  £1ns
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestCellFormattingValues(t *testing.T) {
+	got := formatPlain(t, NewOptions(), []*amount{{pence: 2}, {pence: 3}})
+
+	testsupport.CompareStrings(t, "Output\n£2ns\n£3ns\n", got)
+}
+
+type service struct {
+	Name string
+	Port int
+}
+
+func (s service) String() string {
+	return s.Name
+}
+
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+func (e Endpoint) String() string {
+	return e.Host
+}
+
+type ownedEndpoint struct {
+	Endpoint
+	Owner string
+}
+
+func TestCellFormattingStringerRows(t *testing.T) {
+	got := formatPlain(t, NewOptions(), []service{{Name: "api", Port: 80}, {Name: "db", Port: 5432}})
+
+	testsupport.CompareStrings(t, "Name,Port\napi,80\ndb,5432\n", got)
+
+	got = formatPlain(t, NewOptions(), []*ownedEndpoint{{Endpoint: Endpoint{Host: "api", Port: 80}, Owner: "ops"}})
+
+	testsupport.CompareStrings(t, "Endpoint,Owner\napi,ops\n", got)
+
+	got = formatPlain(t, NewOptions(), []interface{}{service{Name: "api", Port: 80}})
+
+	testsupport.CompareStrings(t, "Name,Port\napi,80\n", got)
+}
+
+type event struct {
+	time.Time
+	Name string
+}
+
+type eventLog struct {
+	Event event
+	Level int
+}
+
+func TestCellFormattingEmbeddedTime(t *testing.T) {
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	// The String method promoted from time.Time does not hide the fields of the struct
+	got := formatPlain(t, NewOptions(), []eventLog{{Event: event{Time: at, Name: "start"}, Level: 1}})
+
+	testsupport.CompareStrings(t, "Time,Name,Level\n2021-03-04 05:06:07 +0000 UTC,start,1\n", got)
+
+	got = formatPlain(t, NewOptions(), map[string]event{"a": {Time: at, Name: "stop"}})
+
+	testsupport.CompareStrings(t, "Key,Time,Name\na,2021-03-04 05:06:07 +0000 UTC,stop\n", got)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"os"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// Color is an ANSI SGR parameter sequence used to style text output, i.e. "1;31" for bold red.
+type Color string
+
+const (
+	// NoStyle leaves text unstyled.
+	NoStyle = Color("")
+	// Bold text.
+	Bold = Color("1")
+	// Faint text.
+	Faint = Color("2")
+	// Italic text.
+	Italic = Color("3")
+	// Underline text.
+	Underline = Color("4")
+	// Black text.
+	Black = Color("30")
+	// Red text.
+	Red = Color("31")
+	// Green text.
+	Green = Color("32")
+	// Yellow text.
+	Yellow = Color("33")
+	// Blue text.
+	Blue = Color("34")
+	// Magenta text.
+	Magenta = Color("35")
+	// Cyan text.
+	Cyan = Color("36")
+	// White text.
+	White = Color("37")
+)
+
+// colorParamName is the name of the tag param used to set a column color.
+const colorParamName = "color"
+
+// noColorEnv is the environment variable that disables styled output when set.
+const noColorEnv = "NO_COLOR"
+
+var namedColors = map[string]Color{
+	"bold":      Bold,
+	"faint":     Faint,
+	"italic":    Italic,
+	"underline": Underline,
+	"black":     Black,
+	"red":       Red,
+	"green":     Green,
+	"yellow":    Yellow,
+	"blue":      Blue,
+	"magenta":   Magenta,
+	"cyan":      Cyan,
+	"white":     White,
+}
+
+// GetColorFromString gets the color for a string.
+// Names may be combined with a '+', i.e. "bold+red", raw SGR parameters such as "38;5;208" are also accepted.
+func GetColorFromString(color string) (Color, error) {
+	parts := make([]string, 0, 2)
+
+	for _, s := range strings.Split(color, "+") {
+		s = strings.ToLower(strings.Trim(s, " "))
+		if s == "" {
+			continue
+		}
+
+		if c, ok := namedColors[s]; ok {
+			parts = append(parts, string(c))
+		} else if isSGRParams(s) {
+			parts = append(parts, s)
+		} else {
+			return NoStyle, lpax.Errorf(langpack.ErrorUnknownColor, s)
+		}
+	}
+
+	return Color(strings.Join(parts, ";")), nil
+}
+
+func isSGRParams(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != ';' {
+			return false
+		}
+	}
+	return true
+}
+
+// apply wraps text in the escape sequences needed to style it.
+func (c Color) apply(text string) string {
+	if c == NoStyle || text == "" {
+		return text
+	}
+	return "\x1b[" + string(c) + "m" + text + "\x1b[0m"
+}
+
+// escapeLen returns the byte length of the ANSI escape sequence at the start of s, or 0 if there is none.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' || s[1] != '[' {
+		return 0
+	}
+
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
+
+// stripEscapes removes any ANSI escape sequences from s.
+func stripEscapes(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+
+	return b.String()
+}
+
+// colorScheme holds the colors applied to a table.
+type colorScheme struct {
+	disabled bool
+	header   Color
+	columns  map[string]Color
+	cells    map[string]map[string]Color
+}
+
+func newColorScheme(options Options) *colorScheme {
+	return &colorScheme{
+		disabled: options.NoColor || os.Getenv(noColorEnv) != "",
+		header:   options.HeaderColor,
+		columns:  options.ColumnColors,
+		cells:    options.CellColors,
+	}
+}
+
+// columnColor returns the color of a column, options take precedence over the tag color.
+func (cs *colorScheme) columnColor(name string, tagInfo *tagData) (Color, error) {
+	if cs == nil || cs.disabled {
+		return NoStyle, nil
+	}
+
+	if c, ok := cs.columns[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+
+	if tagInfo == nil || tagInfo.Params[colorParamName] == "" {
+		return NoStyle, nil
+	}
+
+	return GetColorFromString(tagInfo.Params[colorParamName])
+}
+
+// cellColor returns the color of a cell, conditional cell colors take precedence over the column color.
+func (cs *colorScheme) cellColor(col *column, value string) Color {
+	if cs == nil || cs.disabled {
+		return NoStyle
+	}
+
+	if c, ok := cs.cells[strings.ToLower(col.name)][value]; ok {
+		return c
+	}
+
+	return col.color
+}
+
+// headerColor returns the header color.
+func (cs *colorScheme) headerColor() Color {
+	if cs == nil || cs.disabled {
+		return NoStyle
+	}
+	return cs.header
+}
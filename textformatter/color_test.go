@@ -0,0 +1,169 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+)
+
+func TestGetColorFromString(t *testing.T) {
+	cases := map[string]Color{
+		"":          NoStyle,
+		"red":       Red,
+		"Green":     Green,
+		"bold+red":  Color("1;31"),
+		"38;5;208":  Color("38;5;208"),
+		" cyan + 4": Color("36;4"),
+	}
+
+	for s, expected := range cases {
+		c, err := GetColorFromString(s)
+		if err != nil {
+			t.Errorf("Error %v (%v)", err, s)
+		}
+		if c != expected {
+			t.Errorf("Value %q (%v) expected %q", c, s, expected)
+		}
+	}
+
+	if _, err := GetColorFromString("tartan"); err == nil {
+		t.Error("No error")
+	}
+}
+
+func TestStripEscapes(t *testing.T) {
+	s := stripEscapes(Red.apply("failed") + " " + Bold.apply("now"))
+
+	if s != "failed now" {
+		t.Errorf("unexpected %q", s)
+	}
+}
+
+func TestDisplayWidthIgnoresEscapes(t *testing.T) {
+	if w := displayWidth(Color("1;31").apply("東京")); w != 4 {
+		t.Errorf("unexpected width %d", w)
+	}
+}
+
+type statusData struct {
+	Name   string `tabular:",color=cyan"`
+	Status string
+}
+
+func TestNewFormatterGridColors(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Grid
+	options.HeaderColor = Bold
+	options.CellColors["Status"] = map[string]Color{"ok": Green, "failed": Red}
+
+	err = fmt.Format(&buf, options, []statusData{
+		{Name: "api", Status: "ok"},
+		{Name: "worker", Status: "failed"},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := "+--------+--------+\n" +
+		"| \x1b[1mName\x1b[0m   | \x1b[1mStatus\x1b[0m |\n" +
+		"+--------+--------+\n" +
+		"| \x1b[36mapi\x1b[0m    | \x1b[32mok\x1b[0m     |\n" +
+		"+--------+--------+\n" +
+		"| \x1b[36mworker\x1b[0m | \x1b[31mfailed\x1b[0m |\n" +
+		"+--------+--------+\n"
+
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewFormatterNoColor(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+	options.HeaderColor = Bold
+	options.NoColor = true
+
+	err = fmt.Format(&buf, options, []statusData{
+		{Name: Red.apply("api"), Status: "ok"},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Name,Status\napi,ok\n", buf.String())
+}
+
+func TestNewFormatterNoColorEnv(t *testing.T) {
+	os.Setenv(noColorEnv, "1")
+	defer os.Unsetenv(noColorEnv)
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+	options.ColumnColors["status"] = Yellow
+
+	err = fmt.Format(&buf, options, []statusData{
+		{Name: "api", Status: "ok"},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "Name,Status\napi,ok\n", buf.String())
+}
+
+type badColorData struct {
+	Name string `tabular:",color=tartan"`
+}
+
+func TestNewFormatterBadColorTag(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, nil, []badColorData{{Name: "api"}})
+	if err == nil {
+		t.Error("No color error")
+	}
+}
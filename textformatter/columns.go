@@ -0,0 +1,172 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+// specColumn is a column spec resolved against a struct type.
+type specColumn struct {
+	name       string
+	index      []int
+	rightAlign bool
+	numeric    bool
+	tagInfo    *tagData
+}
+
+// specPlan resolves the column specs of the table against the struct type t.
+func (tablet *tabular) specPlan(t reflect.Type) ([]specColumn, error) {
+	if plan, ok := tablet.specPlans[t]; ok {
+		return plan, nil
+	}
+
+	plan := make([]specColumn, 0, len(tablet.columnSpecs))
+
+	for _, spec := range tablet.columnSpecs {
+		c, ok := resolveSpec(t, spec)
+		if !ok {
+			return nil, lpax.Errorf(langpack.ErrorUnknownField, spec.Field)
+		}
+
+		plan = append(plan, c)
+	}
+
+	if tablet.specPlans == nil {
+		tablet.specPlans = make(map[reflect.Type][]specColumn)
+	}
+	tablet.specPlans[t] = plan
+
+	return plan, nil
+}
+
+// resolveSpec resolves a column spec against the struct type t, ok is false if t has no such field.
+func resolveSpec(t reflect.Type, spec rowset.ColumnSpec) (specColumn, bool) {
+	index, ok := rowset.FieldIndex(t, spec.Field)
+	if !ok {
+		return specColumn{}, false
+	}
+
+	field := rowset.StructFieldByIndex(t, index)
+	tagInfo := getTags(field.Tag, tabularTagName)
+
+	return specColumn{
+		name:       spec.Header(getFieldName(field.Name, tagInfo)),
+		index:      index,
+		rightAlign: isRightAligned(field.Type),
+		numeric:    isNumeric(field.Type),
+		tagInfo:    tagInfo,
+	}, true
+}
+
+// reflectSpecHeader adds the columns selected by the column specs in their specified order.
+func reflectSpecHeader(table *tabular, t reflect.Type) error {
+	plan, err := table.specPlan(t)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range plan {
+		if _, err := table.addFieldColumn(c.name, c.rightAlign, c.numeric, c.tagInfo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reflectSpecRow sets the fields of a row from the column specs, starting at column col.
+func reflectSpecRow(table *tabular, row rowID, col colID, value reflect.Value) error {
+	plan, err := table.specPlan(value.Type())
+	if err != nil {
+		return err
+	}
+
+	for i, c := range plan {
+		if err := reflectSpecCell(table, row, col+colID(i), value, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reflectSpecCell sets a field of a row from the struct field selected by a column spec.
+func reflectSpecCell(table *tabular, row rowID, col colID, value reflect.Value, c specColumn) error {
+	text, align := table.specValue(value, c)
+
+	if err := table.setField(row, col, "%s", text); err != nil {
+		return err
+	}
+
+	table.alignColumn(col, align)
+
+	if v, ok := rowset.FieldByIndex(value, c.index); ok {
+		table.accumulate(row, col, v)
+	}
+
+	return nil
+}
+
+// reflectSpecDetail outputs the fields selected by the column specs as name value pairs.
+func reflectSpecDetail(ctx context.Context, table *tabular, value reflect.Value) error {
+	plan, err := table.specPlan(value.Type())
+	if err != nil {
+		return err
+	}
+
+	_, _ = table.addColumn("Name", true, nil)
+	_, _ = table.addColumn("Output", false, nil)
+
+	for _, c := range plan {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		row := table.newRow()
+
+		if err := table.setField(row, colID(0), "%s", c.name); err != nil {
+			return err
+		}
+		text, _ := table.specValue(value, c)
+		if err := table.setField(row, colID(1), "%s", text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// specValue returns the text of a field and its alignment hint.
+func (tablet *tabular) specValue(value reflect.Value, c specColumn) (string, yaff.Alignment) {
+	v, ok := rowset.FieldByIndex(value, c.index)
+
+	switch {
+	case !ok:
+		return tablet.nilText(c.tagInfo), yaff.AlignDefault
+	case v.Kind() == reflect.Bool && c.tagInfo != nil && c.tagInfo.Options["trueonly"] && !v.Bool():
+		return "", yaff.AlignDefault
+	default:
+		return tablet.cellValue(v, c.tagInfo)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+// NewDecoder return a new decoder reading plain text tables.
+func NewDecoder() (yaff.Decoder, error) {
+	return &decoder{}, nil
+}
+
+type decoder struct {
+	header []string
+}
+
+// Decode reads a plain or aligned text table, the options default to those of the formatter and must match
+// those it was written with.  Aligned cells are split at the spaces between the header names, so header
+// names cannot contain spaces and without a header cells cannot either.  Other styles cannot be decoded.
+// Blank lines and lines repeating the header are skipped.
+func (d *decoder) Decode(reader io.Reader, options yaff.FormatOptions, target interface{}) error {
+	textOptions, err := getOptions(options)
+	if err != nil {
+		return err
+	}
+
+	if textOptions.Style != Plain && textOptions.Style != Aligned {
+		return lpax.Errorf(langpack.ErrorStyleNotDecodable, textOptions.Style)
+	}
+
+	t, err := rowset.NewTarget(target)
+	if err != nil {
+		return err
+	}
+
+	var det *Detabulator
+	var headerLine string
+	var starts, ends []int
+
+	d.header = nil
+	if textOptions.ExcludeHeader {
+		if det, err = NewDetabulator(textOptions, t.Type(), nil); err != nil {
+			return err
+		}
+		d.header = det.Header()
+	}
+
+	in := bufio.NewReader(reader)
+
+	for line := 1; ; line++ {
+		text, readErr := in.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		// Styling is removed before the line is split, so columns count display positions
+		text = stripEscapes(strings.TrimRight(text, "\r\n"))
+
+		if strings.TrimSpace(text) != "" && text != headerLine {
+			var cells []string
+			var columns []int
+
+			switch {
+			case textOptions.Style == Plain:
+				cells, columns = splitCells(text, textOptions.ColumnSeparator)
+			case starts == nil:
+				var fieldEnds []int
+				cells, columns, fieldEnds = splitFields(text)
+				if det == nil {
+					starts, ends = columns, fieldEnds
+				}
+			default:
+				cells, columns = splitAligned(text, starts, ends)
+			}
+
+			if det == nil {
+				headerLine = text
+				if det, err = NewDetabulator(textOptions, t.Type(), cells); err != nil {
+					return &yaff.DecodeError{Line: line, Err: err}
+				}
+				d.header = det.Header()
+			} else {
+				record := t.New()
+				if i, err := det.Decode(cells, record); err != nil {
+					if i < 0 {
+						return &yaff.DecodeError{Line: line, Err: err}
+					}
+					return &yaff.DecodeError{Line: line, Column: columns[i], Err: err}
+				}
+				t.Add(record)
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// Header returns the column names of the table last decoded.
+func (d *decoder) Header() []string {
+	return d.header
+}
+
+// splitCells splits a line into its cells and the column each cell starts at.
+func splitCells(line, separator string) ([]string, []int) {
+	cells := strings.Split(line, separator)
+	columns := make([]int, len(cells))
+
+	column := 1
+	for i, cell := range cells {
+		columns[i] = column
+		column += utf8.RuneCountInString(cell) + utf8.RuneCountInString(separator)
+	}
+
+	return cells, columns
+}
+
+// splitFields splits an aligned line into its space separated fields, returning the display column,
+// counting from 1, each field starts at and the display column following its end.
+func splitFields(line string) ([]string, []int, []int) {
+	var cells []string
+	var starts, ends []int
+
+	column := 1
+	start := -1
+
+	for i, r := range line {
+		switch {
+		case r != ' ' && start < 0:
+			start = i
+			starts = append(starts, column)
+		case r == ' ' && start >= 0:
+			cells = append(cells, line[start:i])
+			ends = append(ends, column)
+			start = -1
+		}
+		column += runeWidth(r)
+	}
+
+	if start >= 0 {
+		cells = append(cells, line[start:])
+		ends = append(ends, column)
+	}
+
+	return cells, starts, ends
+}
+
+// splitAligned splits an aligned line into the cells beneath the header names and the display column each
+// cell starts at.  The columns are separated by the last space between the end of one header name and the
+// start of the next.  If a cell overflows into the next column fewer cells are returned.
+func splitAligned(line string, starts, ends []int) ([]string, []int) {
+	runes := []rune(line)
+	positions := make([]int, len(runes)+1)
+
+	positions[0] = 1
+	for i, r := range runes {
+		positions[i+1] = positions[i] + runeWidth(r)
+	}
+
+	cells := make([]string, 0, len(starts))
+	columns := make([]int, 0, len(starts))
+
+	from := 0
+	for i := range starts {
+		to := len(runes)
+
+		if i+1 < len(starts) {
+			to = -1
+
+			k := from
+			for ; k < len(runes) && positions[k] < starts[i+1]; k++ {
+				if runes[k] == ' ' && positions[k] >= ends[i] {
+					to = k
+				}
+			}
+
+			if to < 0 && k < len(runes) {
+				return cells, columns
+			} else if to < 0 {
+				to = len(runes)
+			}
+		}
+
+		cell := string(runes[from:to])
+		trimmed := strings.TrimLeft(cell, " ")
+
+		cells = append(cells, strings.TrimRight(trimmed, " "))
+		columns = append(columns, positions[from+len(cell)-len(trimmed)])
+
+		if from = to + 1; from > len(runes) {
+			from = len(runes)
+		}
+	}
+
+	return cells, columns
+}
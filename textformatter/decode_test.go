@@ -0,0 +1,198 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+type decodeData struct {
+	Name   string
+	Count  *int `tabular:",nil=none"`
+	When   *time.Time
+	Tags   []string `tabular:",sep=|"`
+	Sizes  [2]int
+	Labels map[string]int
+	Took   time.Duration
+	Ready  bool `tabular:",trueonly"`
+	Node   *nodeData
+}
+
+func decodeRows() []decodeData {
+	count := 3
+	when := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	return []decodeData{
+		{
+			Name: "full", Count: &count, When: &when, Tags: []string{"a", "b"}, Sizes: [2]int{1, 2},
+			Labels: map[string]int{"x": 1, "y": 2}, Took: 90 * time.Second, Ready: true, Node: &nodeData{Name: "n1"},
+		},
+		{Name: "empty"},
+	}
+}
+
+func decodePlain(t *testing.T, options yaff.FormatOptions, input string, target interface{}) error {
+	t.Helper()
+
+	dec, err := yaff.Formatters().GetDecoder(Text)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	return dec.Decode(strings.NewReader(input), options, target)
+}
+
+func TestDecodePlainRoundTrip(t *testing.T) {
+	options := NewOptions()
+	options.Style = Plain
+	options.NilText = "-"
+	options.HeaderColor = Bold
+
+	fmt, _ := NewFormatter()
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, decodeRows()); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var got []decodeData
+	if err := decodePlain(t, options, buf.String(), &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if !reflect.DeepEqual(got, decodeRows()) {
+		t.Errorf("got %v expected %v", got, decodeRows())
+	}
+}
+
+func TestDecodeAlignedRoundTrip(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	rows := append(decodeRows(), decodeData{Name: "wide name", Tags: []string{"a b"}, Took: time.Hour})
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, nil, rows); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var got []decodeData
+	if err := decodePlain(t, nil, buf.String(), &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("got %v expected %v", got, rows)
+	}
+}
+
+func TestDecodeAlignedError(t *testing.T) {
+	var got []decodeData
+	err := decodePlain(t, nil, "Name  Count\na     none\nbb     many\n", &got)
+
+	var decodeError *yaff.DecodeError
+	if !errors.As(err, &decodeError) || decodeError.Line != 3 || decodeError.Column != 8 {
+		t.Errorf("err %v", err)
+	}
+
+	err = decodePlain(t, nil, "Name  Count\nabcdefgh 1\n", &got)
+	if err == nil || err.Error() != (&yaff.DecodeError{Line: 2, Err: lpax.Errorf(langpack.ErrorFieldCount, 0, 2)}).Error() {
+		t.Errorf("err %v", err)
+	}
+}
+
+func TestDecodePlainDefaultOptions(t *testing.T) {
+	var got []*nodeData
+	if err := decodePlain(t, nil, "Name\na\n\nName\nb\n", &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "b" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodePlainExcludeHeader(t *testing.T) {
+	options := NewOptions()
+	options.Style = Plain
+	options.ExcludeHeader = true
+	options.Columns = rowset.ParseColumnSpecs("Took as Time,Name")
+
+	var got []decodeData
+	if err := decodePlain(t, options, "1m0s\ta\n", &got); err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if len(got) != 1 || got[0].Name != "a" || got[0].Took != time.Minute {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodePlainStyle(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+
+	var got []decodeData
+	err := decodePlain(t, options, "Name\n", &got)
+
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorStyleNotDecodable, Grid) {
+		t.Error("err:", err)
+	}
+}
+
+func TestDecodePlainError(t *testing.T) {
+	options := NewOptions()
+	options.Style = Plain
+
+	var got []decodeData
+	err := decodePlain(t, options, "Name\tCount\na\tnone\nb\tmany\n", &got)
+
+	var decodeError *yaff.DecodeError
+	if !errors.As(err, &decodeError) || decodeError.Line != 3 || decodeError.Column != 3 {
+		t.Errorf("err %v", err)
+	}
+
+	err = decodePlain(t, options, "Name\tCount\na\n", &got)
+	if err == nil || err.Error() != (&yaff.DecodeError{Line: 2, Err: lpax.Errorf(langpack.ErrorFieldCount, 1, 2)}).Error() {
+		t.Errorf("err %v", err)
+	}
+}
+
+func TestDetabulatorUnknownColumns(t *testing.T) {
+	_, err := NewDetabulator(NewOptions(), reflect.TypeOf(decodeData{}), []string{"Name", "Size", "Count", "Owner"})
+
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorUnknownColumns, "Size, Owner", reflect.TypeOf(decodeData{})) {
+		t.Error("err:", err)
+	}
+}
+
+func TestDetabulatorRecordType(t *testing.T) {
+	_, err := NewDetabulator(NewOptions(), reflect.TypeOf(0), nil)
+
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorDecodeRecord, reflect.TypeOf(0)) {
+		t.Error("err:", err)
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"context"
+	"encoding"
+	"reflect"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Detabulator decodes rows of text cells, named by the header a Tabulator outputs, into records,
+// reversing the column plan of the text formatter.
+type Detabulator struct {
+	table   *tabular
+	keyed   bool
+	header  []string
+	columns []*decodeColumn
+}
+
+// decodeColumn is the struct field a column is decoded into.
+type decodeColumn struct {
+	name    string
+	index   []int
+	tagInfo *tagData
+	// nilable is true if the field, or a struct it is in, can be nil.
+	nilable bool
+	// skip is true if the column is output but its field cannot be decoded.
+	skip bool
+}
+
+// NewDetabulator returns a detabulator decoding rows into records of type t, a struct, pointer to a struct
+// or map with string keys.  Header names are matched, ignoring case, to the names of the columns the text
+// formatter outputs for the struct, including any Columns aliases, and an error listing any unknown names
+// is returned.  When header is nil the columns output for the struct using the options are expected.
+func NewDetabulator(options Options, t reflect.Type, header []string) (*Detabulator, error) {
+	options = normalizeOptions(options)
+	d := &Detabulator{table: newStyledTabular(options), keyed: rowset.IsKeyed(t)}
+
+	st := t
+	for st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+
+	if d.keyed && header != nil {
+		d.header = cleanHeader(header)
+		d.columns = make([]*decodeColumn, len(header))
+		return d, nil
+	}
+
+	if st.Kind() != reflect.Struct || isCellValue(st) {
+		return nil, lpax.Errorf(langpack.ErrorDecodeRecord, t)
+	}
+
+	if header == nil {
+		tab := NewTabulator(options)
+		if _, err := tab.Tabulate(context.Background(), reflect.MakeSlice(reflect.SliceOf(t), 0, 0).Interface()); err != nil {
+			return nil, err
+		}
+		header = tab.Header()
+	}
+
+	plan, err := decodePlan(st, options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	d.header = cleanHeader(header)
+	d.columns = make([]*decodeColumn, len(header))
+	used := make([]bool, len(plan))
+
+	var unknown []string
+
+	// Repeated names are matched to columns in order
+	for i, name := range d.header {
+		for j, c := range plan {
+			if !used[j] && strings.EqualFold(c.name, name) {
+				d.columns[i] = c
+				used[j] = true
+				break
+			}
+		}
+
+		if d.columns[i] == nil {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, lpax.Errorf(langpack.ErrorUnknownColumns, strings.Join(unknown, ", "), t)
+	}
+
+	return d, nil
+}
+
+// decodePlan returns the columns of a struct type t, in the order the text formatter outputs them.
+func decodePlan(t reflect.Type, specs []rowset.ColumnSpec) ([]*decodeColumn, error) {
+	plan := make([]*decodeColumn, 0, t.NumField())
+
+	if len(specs) > 0 {
+		for _, spec := range specs {
+			c, ok := resolveSpec(t, spec)
+			if !ok {
+				return nil, lpax.Errorf(langpack.ErrorUnknownField, spec.Field)
+			}
+
+			plan = append(plan, &decodeColumn{name: c.name, index: c.index, tagInfo: c.tagInfo, nilable: isNilablePath(t, c.index)})
+		}
+
+		return plan, nil
+	}
+
+	l := layoutFor(t)
+	paths := make([][]int, len(l.fields))
+	types := make([]reflect.Type, len(l.fields))
+
+	for i, f := range l.fields {
+		parent := t
+		if f.parent >= 0 {
+			paths[i] = append(append([]int{}, paths[f.parent]...), f.index)
+			parent = types[f.parent]
+		} else {
+			paths[i] = []int{f.index}
+		}
+
+		types[i] = parent.Field(f.index).Type
+		for types[i].Kind() == reflect.Ptr {
+			types[i] = types[i].Elem()
+		}
+
+		if f.layout == layoutColumn {
+			plan = append(plan, &decodeColumn{
+				name:    f.name,
+				index:   paths[i],
+				tagInfo: f.tagInfo,
+				nilable: isNilablePath(t, paths[i]),
+				skip:    !isDecodable(types[i]),
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// cleanHeader removes escape sequences and surrounding spaces from header names.
+func cleanHeader(header []string) []string {
+	names := make([]string, len(header))
+	for i, name := range header {
+		names[i] = strings.TrimSpace(stripEscapes(name))
+	}
+	return names
+}
+
+// Header returns the names of the columns decoded.
+func (d *Detabulator) Header() []string {
+	return d.header
+}
+
+// Decode sets the fields of the settable record from the cells of a row.  If a cell cannot be decoded
+// its index is returned with the error, the index is -1 if the row has the wrong number of cells.
+// Empty cells and the nil text of pointers, slices and maps leave their field unset.
+func (d *Detabulator) Decode(row []string, record reflect.Value) (int, error) {
+	if len(row) != len(d.header) {
+		return -1, lpax.Errorf(langpack.ErrorFieldCount, len(row), len(d.header))
+	}
+
+	if d.keyed {
+		return d.decodeKeyed(row, rowset.Allocate(record))
+	}
+
+	for i, text := range row {
+		c := d.columns[i]
+
+		if c.skip || text == "" {
+			continue
+		}
+
+		if c.nilable && text == d.table.nilText(c.tagInfo) {
+			continue
+		}
+
+		if err := d.setValue(rowset.SettableField(record, c.index), text, c.tagInfo); err != nil {
+			return i, err
+		}
+	}
+
+	return -1, nil
+}
+
+// decodeKeyed sets the keys of a map named by the header to their cells.
+func (d *Detabulator) decodeKeyed(row []string, record reflect.Value) (int, error) {
+	if record.IsNil() {
+		record.Set(reflect.MakeMap(record.Type()))
+	}
+
+	t := record.Type()
+
+	for i, text := range row {
+		v := reflect.New(t.Elem()).Elem()
+		if err := d.setValue(v, text, nil); err != nil {
+			return i, err
+		}
+
+		record.SetMapIndex(reflect.ValueOf(d.header[i]).Convert(t.Key()), v)
+	}
+
+	return -1, nil
+}
+
+// setValue sets a value from text, slices, arrays and maps are split into items by the list separator
+// and map items are split into their key and value at the first '='.
+func (d *Detabulator) setValue(v reflect.Value, text string, tagInfo *tagData) error {
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if !isCompositeKind(t.Kind()) || rowset.HasCellText(t) {
+		return rowset.SetCellText(v, text)
+	}
+
+	v = rowset.Allocate(v)
+	items := strings.Split(text, d.table.separator(tagInfo))
+
+	switch t.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(t, len(items), len(items)))
+
+	case reflect.Map:
+		v.Set(reflect.MakeMapWithSize(t, len(items)))
+
+		for _, item := range items {
+			parts := strings.SplitN(item, "=", 2)
+			key, value := reflect.New(t.Key()).Elem(), reflect.New(t.Elem()).Elem()
+
+			if err := rowset.SetCellText(key, parts[0]); err != nil {
+				return err
+			}
+			if len(parts) > 1 {
+				if err := d.setValue(value, parts[1], tagInfo); err != nil {
+					return err
+				}
+			}
+
+			v.SetMapIndex(key, value)
+		}
+
+		return nil
+	}
+
+	for i := 0; i < len(items) && i < v.Len(); i++ {
+		if err := d.setValue(v.Index(i), items[i], tagInfo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isDecodable returns true if values of the type t can be decoded from text, structs must implement encoding.TextUnmarshaler.
+func isDecodable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return reflect.PtrTo(t).Implements(textUnmarshalerType)
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// isNilablePath returns true if the field of the struct type t with the index sequence, or any struct
+// pointer along the path, can be nil.
+func isNilablePath(t reflect.Type, index []int) bool {
+	for _, i := range index {
+		if t.Kind() == reflect.Ptr {
+			return true
+		}
+		t = t.Field(i).Type
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+const (
+	// formatParamName is the name of the tag param holding a fmt verb used to format a value, i.e. format=%.2f.
+	formatParamName = "format"
+
+	// unitParamName is the name of the tag param used to output numbers in a unit, i.e. unit=bytes.
+	unitParamName = "unit"
+
+	// timeParamName is the name of the tag param used to format times, a layout name, relative or a time layout.
+	timeParamName = "time"
+
+	// durationParamName is the name of the tag param used to format durations, i.e. duration=short.
+	durationParamName = "duration"
+
+	// percentOptionName is the name of the tag option used to output a ratio as a percentage.
+	percentOptionName = "percent"
+
+	bytesUnit     = "bytes"
+	relativeTime  = "relative"
+	shortDuration = "short"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+
+	// timeLayouts are the named layouts accepted by the time tag param.
+	timeLayouts = map[string]string{
+		"ansic":       time.ANSIC,
+		"unixdate":    time.UnixDate,
+		"rfc822":      time.RFC822,
+		"rfc822z":     time.RFC822Z,
+		"rfc850":      time.RFC850,
+		"rfc1123":     time.RFC1123,
+		"rfc1123z":    time.RFC1123Z,
+		"rfc3339":     time.RFC3339,
+		"rfc3339nano": time.RFC3339Nano,
+		"kitchen":     time.Kitchen,
+		"stamp":       time.Stamp,
+		"date":        "2006-01-02",
+		"datetime":    "2006-01-02 15:04:05",
+	}
+
+	byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+	// now returns the time relative times are measured from.
+	now = time.Now
+)
+
+// valueFormat holds the format settings of a field taken from its tag.
+type valueFormat struct {
+	verb          string
+	bytes         bool
+	timeLayout    string
+	relativeTime  bool
+	shortDuration bool
+	percent       bool
+}
+
+// newValueFormat returns the format of a field from its tag options and params, or nil if the field
+// has no format settings.
+func newValueFormat(options map[string]bool, params map[string]string) *valueFormat {
+	f := valueFormat{
+		verb:          params[formatParamName],
+		bytes:         strings.EqualFold(params[unitParamName], bytesUnit),
+		shortDuration: strings.EqualFold(params[durationParamName], shortDuration),
+		percent:       options[percentOptionName],
+	}
+
+	if layout := params[timeParamName]; strings.EqualFold(layout, relativeTime) {
+		f.relativeTime = true
+	} else if named, ok := timeLayouts[strings.ToLower(layout)]; ok {
+		f.timeLayout = named
+	} else {
+		f.timeLayout = layout
+	}
+
+	if f == (valueFormat{}) {
+		return nil
+	}
+	return &f
+}
+
+// formatValue formats v using the format settings of the tag, ok is false if the settings do not apply to v.
+func (tagData *tagData) formatValue(v reflect.Value) (text string, ok bool) {
+	if tagData == nil || tagData.format == nil {
+		return "", false
+	}
+	return tagData.format.apply(v)
+}
+
+func (f *valueFormat) apply(v reflect.Value) (string, bool) {
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if f.relativeTime {
+			return relativeText(now().Sub(t)), true
+		}
+		if f.timeLayout != "" {
+			return t.Format(f.timeLayout), true
+		}
+
+	case v.Type() == durationType && f.shortDuration:
+		return shortDurationText(time.Duration(v.Int())), true
+	}
+
+	n, isNumber := rowset.Number(v)
+
+	switch {
+	case isNumber && f.bytes:
+		return f.bytesText(n), true
+	case isNumber && f.percent:
+		return f.number(n*100, "") + "%", true
+	case f.verb != "":
+		arg, ok := verbArg(f.verb, v)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf(f.verb, arg), true
+	default:
+		return "", false
+	}
+}
+
+// verbArg returns the argument formatted by the single verb of format for v, ok is false if format does not
+// have a single verb or the verb does not apply to the kind of v.  Integers are converted to float64 for
+// floating point verbs.
+func verbArg(format string, v reflect.Value) (arg interface{}, ok bool) {
+	verb, ok := formatVerb(format)
+	if !ok {
+		return nil, false
+	}
+
+	switch k := v.Kind(); {
+	case verb == 'v' || verb == 'T':
+	case strings.ContainsRune("sqxX", verb) && (v.Type().Implements(stringerType) || v.Type().Implements(errorType)):
+	case k >= reflect.Int && k <= reflect.Uintptr && strings.ContainsRune("eEfFgG", verb):
+		n, _ := rowset.Number(v)
+		return n, true
+	case k >= reflect.Int && k <= reflect.Uintptr:
+		return v.Interface(), strings.ContainsRune("bcdoOqxXU", verb)
+	case k >= reflect.Float32 && k <= reflect.Complex128:
+		return v.Interface(), strings.ContainsRune("beEfFgGxX", verb)
+	case k == reflect.String:
+		return v.Interface(), strings.ContainsRune("sqxX", verb)
+	case k == reflect.Bool:
+		return v.Interface(), verb == 't'
+	default:
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+// formatVerb returns the verb of a format, ok is false unless it has a single verb.
+func formatVerb(format string) (verb rune, ok bool) {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		// Skip the flags, width, precision and argument index
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0123456789.*[]", format[i]) >= 0 {
+			i++
+		}
+
+		if i >= len(format) || format[i] == '%' {
+			continue
+		}
+
+		if ok {
+			return 0, false
+		}
+
+		r, size := utf8.DecodeRuneInString(format[i:])
+		verb, ok = r, true
+		i += size - 1
+	}
+
+	return verb, ok
+}
+
+// number formats n with the format verb, or def if set, or as the shortest decimal to two places.
+func (f *valueFormat) number(n float64, def string) string {
+	if arg, ok := verbArg(f.verb, reflect.ValueOf(n)); ok {
+		return fmt.Sprintf(f.verb, arg)
+	}
+
+	switch {
+	case def != "":
+		return fmt.Sprintf(def, n)
+	default:
+		return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+	}
+}
+
+// bytesText outputs a number of bytes in the largest binary unit that keeps the value at or above one.
+func (f *valueFormat) bytesText(n float64) string {
+	unit := 0
+	for math.Abs(n) >= 1024 && unit < len(byteUnits)-1 {
+		n /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return f.number(n, "%.0f") + " " + byteUnits[unit]
+	}
+	return f.number(n, "%.1f") + " " + byteUnits[unit]
+}
+
+// shortDurationText outputs a duration rounded to a precision suited to its size with zero units removed,
+// i.e. 2d3h rather than 51h0m0s.
+func shortDurationText(d time.Duration) string {
+	if d < 0 {
+		return "-" + shortDurationText(-d)
+	}
+
+	switch {
+	case d >= time.Minute:
+		d = d.Round(time.Second)
+	case d >= time.Second:
+		d = d.Round(time.Millisecond)
+	}
+
+	var days string
+	if d >= 24*time.Hour {
+		days = strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+		d %= 24 * time.Hour
+		if d == 0 {
+			return days
+		}
+	}
+
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+
+	return days + s
+}
+
+// relativeText outputs the age of a time in its largest whole unit, i.e. 3 hours ago or in 2 days.
+func relativeText(age time.Duration) string {
+	future := age < 0
+	if future {
+		age = -age
+	}
+
+	units := []struct {
+		size time.Duration
+		text langpack.TextID
+	}{
+		{365 * 24 * time.Hour, langpack.TextYears},
+		{30 * 24 * time.Hour, langpack.TextMonths},
+		{24 * time.Hour, langpack.TextDays},
+		{time.Hour, langpack.TextHours},
+		{time.Minute, langpack.TextMinutes},
+		{time.Second, langpack.TextSeconds},
+	}
+
+	for _, u := range units {
+		n := int64(age / u.size)
+		if n == 0 {
+			continue
+		}
+
+		text := lpax.Sprintf(lpax.ByCount(u.text, int(n)), n)
+
+		if future {
+			return lpax.Sprintf(langpack.TextRelativeFuture, text)
+		}
+		return lpax.Sprintf(langpack.TextRelativePast, text)
+	}
+
+	return lpax.Sprintf(langpack.TextRelativeNow)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nehemming/testsupport"
+)
+
+type formatData struct {
+	Name    string
+	Ratio   float64       `tabular:",format=%.2f"`
+	Size    int64         `tabular:",unit=bytes"`
+	Created time.Time     `tabular:",time=date"`
+	Seen    *time.Time    `tabular:",time=relative,nil=never"`
+	Took    time.Duration `tabular:",duration=short"`
+	Usage   float64       `tabular:",percent"`
+	Scores  []float64     `tabular:",format=%.1f,sep=/"`
+}
+
+func formatRows() []formatData {
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	seen := created.Add(-3 * time.Hour)
+
+	return []formatData{
+		{
+			Name: "one", Ratio: 3.140000001, Size: 1536, Created: created, Seen: &seen,
+			Took: 90*time.Minute + 300*time.Millisecond, Usage: 0.256, Scores: []float64{1, 2.25},
+		},
+		{Name: "two", Ratio: 1, Size: 12, Created: created, Took: 1500 * time.Millisecond, Usage: 1},
+	}
+}
+
+func TestFormatParams(t *testing.T) {
+	defer func(fn func() time.Time) { now = fn }(now)
+	now = func() time.Time { return time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC) }
+
+	got := formatPlain(t, NewOptions(), formatRows())
+
+	expected := `Name,Ratio,Size,Created,Seen,Took,Usage,Scores
+one,3.14,1.5 KiB,2021-03-04,3 hours ago,1h30m,25.6%,1.0/2.2
+two,1.00,12 B,2021-03-04,never,1.5s,100%,
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestFormatParamsDetail(t *testing.T) {
+	options := NewOptions()
+	options.ColumnSet = map[string]bool{"ratio": true, "size": true, "took": true}
+
+	got := formatPlain(t, options, formatRows()[0])
+
+	expected := `Name,Output
+Ratio,3.14
+Size,1.5 KiB
+Took,1h30m
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestShortDurationText(t *testing.T) {
+	cases := map[time.Duration]string{
+		0:                                    "0s",
+		250 * time.Microsecond:               "250µs",
+		1500 * time.Millisecond:              "1.5s",
+		2*time.Minute + 400*time.Millisecond: "2m",
+		time.Hour + 5*time.Second:            "1h0m5s",
+		51 * time.Hour:                       "2d3h",
+		48 * time.Hour:                       "2d",
+		-90 * time.Second:                    "-1m30s",
+	}
+
+	for d, expected := range cases {
+		if got := shortDurationText(d); got != expected {
+			t.Errorf("%v: expected %q got %q", int64(d), expected, got)
+		}
+	}
+}
+
+func TestRelativeText(t *testing.T) {
+	cases := map[time.Duration]string{
+		0:                    "now",
+		time.Second:          "1 second ago",
+		-2 * 24 * time.Hour:  "in 2 days",
+		400 * 24 * time.Hour: "1 year ago",
+	}
+
+	for d, expected := range cases {
+		if got := relativeText(d); got != expected {
+			t.Errorf("%v: expected %q got %q", d, expected, got)
+		}
+	}
+}
+
+type verbData struct {
+	Name  string  `tabular:",format=%.1f"`
+	Count int     `tabular:",format=%.1f"`
+	Ratio float64 `tabular:",format=%d"`
+	Code  int     `tabular:",format=%04d"`
+	Label string  `tabular:",format=%q"`
+}
+
+func TestFormatVerbKinds(t *testing.T) {
+	got := formatPlain(t, NewOptions(), []verbData{{Name: "a", Count: 3, Ratio: 0.5, Code: 7, Label: "x"}})
+
+	expected := `Name,Count,Ratio,Code,Label
+a,3.0,0.5,0007,"x"
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestFormatVerb(t *testing.T) {
+	cases := map[string]rune{
+		"%d":       'd',
+		"%-8.2f":   'f',
+		"%%%x%%":   'x',
+		"%[1]s":    's',
+		"%d of %d": 0,
+		"100%%":    0,
+		"%":        0,
+	}
+
+	for format, expected := range cases {
+		if verb, ok := formatVerb(format); verb != expected || ok != (expected != 0) {
+			t.Errorf("%q: expected %q got %q %v", format, expected, verb, ok)
+		}
+	}
+}
+
+func TestBytesText(t *testing.T) {
+	f := newValueFormat(nil, map[string]string{unitParamName: "bytes"})
+
+	cases := map[float64]string{
+		0:                       "0 B",
+		1023:                    "1023 B",
+		1024:                    "1.0 KiB",
+		5 * 1024 * 1024:         "5.0 MiB",
+		-3 * 1024 * 1024 * 1024: "-3.0 GiB",
+	}
+
+	for n, expected := range cases {
+		if got := f.bytesText(n); got != expected {
+			t.Errorf("%v: expected %q got %q", n, expected, got)
+		}
+	}
+}
+
+func TestNewValueFormatNone(t *testing.T) {
+	if f := newValueFormat(map[string]bool{"trueonly": true}, map[string]string{"width": "10"}); f != nil {
+		t.Errorf("Unexpected format %v", f)
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+// group is a run of rows with the same values in the group by columns.
+type group struct {
+	title  string
+	start  int
+	end    int
+	totals []string
+}
+
+// groupRows orders the rows into groups by the values of the group by columns, the groups are in
+// the order of their first row, and totals each group.  When merge is set the group by columns are
+// moved to the front of the table so grid styles can merge their cells.  nil is returned if the rows
+// are not grouped.
+func (tablet *tabular) groupRows(merge bool) ([]group, error) {
+	if len(tablet.groupBy) == 0 || !tablet.isArray || len(tablet.rows) == 0 {
+		return nil, nil
+	}
+
+	cols := make([]int, len(tablet.groupBy))
+	for i, name := range tablet.groupBy {
+		cols[i] = -1
+		for c, col := range tablet.columns {
+			if strings.EqualFold(col.name, name) {
+				cols[i] = c
+				break
+			}
+		}
+
+		if cols[i] < 0 {
+			return nil, lpax.Errorf(langpack.ErrorUnknownField, name)
+		}
+	}
+
+	if merge {
+		tablet.moveColumns(cols)
+		for i := range cols {
+			cols[i] = i
+		}
+	}
+
+	index := make(map[string]int)
+	var titles []string
+	var members [][]int
+
+	for r, row := range tablet.rows {
+		values := make([]string, len(cols))
+		for i, c := range cols {
+			values[i] = row[c]
+		}
+
+		key := strings.Join(values, "\x00")
+		g, ok := index[key]
+		if !ok {
+			g = len(members)
+			index[key] = g
+			titles = append(titles, tablet.groupTitle(cols, values))
+			members = append(members, nil)
+		}
+
+		members[g] = append(members[g], r)
+	}
+
+	groups := make([]group, len(members))
+	order := make([]int, 0, len(tablet.rows))
+
+	for g, rows := range members {
+		groups[g] = group{title: titles[g], start: len(order), end: len(order) + len(rows)}
+		order = append(order, rows...)
+	}
+
+	tablet.reorderRows(order)
+
+	merged := 0
+	if merge {
+		merged = tablet.mergedColumns()
+	}
+
+	for g := range groups {
+		groups[g].totals = mergeCells(tablet.subtotal(groups[g], merged), merged)
+	}
+
+	return groups, nil
+}
+
+// mergedColumns returns the number of leading group columns merged by grid styles, at least one column is not merged.
+func (tablet *tabular) mergedColumns() int {
+	if len(tablet.groupBy) >= len(tablet.columns) {
+		return len(tablet.columns) - 1
+	}
+	return len(tablet.groupBy)
+}
+
+// groupTitle returns the title of a group, i.e. "Status: failed, Zone: a".
+func (tablet *tabular) groupTitle(cols []int, values []string) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = lpax.Sprintf(langpack.TextGroupValue, tablet.columns[c].name, values[i])
+	}
+	return strings.Join(parts, lpax.Sprintf(langpack.TextGroupSeparator))
+}
+
+// moveColumns moves the columns cols to the front of the table in their given order.
+func (tablet *tabular) moveColumns(cols []int) {
+	order := append([]int{}, cols...)
+	for c := range tablet.columns {
+		moved := false
+		for _, m := range cols {
+			moved = moved || m == c
+		}
+		if !moved {
+			order = append(order, c)
+		}
+	}
+
+	columns := make([]*column, len(order))
+	for i, c := range order {
+		columns[i] = tablet.columns[c]
+	}
+	tablet.columns = columns
+
+	for r, row := range tablet.rows {
+		fields := make([]string, len(order))
+		for i, c := range order {
+			fields[i] = row[c]
+		}
+		tablet.rows[r] = fields
+	}
+
+	for r, cells := range tablet.cells {
+		if cells == nil {
+			continue
+		}
+
+		values := make([]reflect.Value, len(order))
+		for i, c := range order {
+			values[i] = cells[c]
+		}
+		tablet.cells[r] = values
+	}
+}
+
+// reorderRows puts the rows, along with their nested tables and cell values, in the order given.
+func (tablet *tabular) reorderRows(order []int) {
+	rows := make([][]string, len(order))
+	nested := make([][]nestedTable, len(order))
+	var cells [][]reflect.Value
+	if len(tablet.cells) > 0 {
+		cells = make([][]reflect.Value, len(order))
+	}
+
+	for i, r := range order {
+		rows[i] = tablet.rows[r]
+		nested[i] = tablet.nested[r]
+		if cells != nil && r < len(tablet.cells) {
+			cells[i] = tablet.cells[r]
+		}
+	}
+
+	tablet.rows, tablet.nested, tablet.cells = rows, nested, cells
+}
+
+// subtotal returns the subtotals row of a group, or nil if there are no subtotals.
+// The label is placed in the first column after any merged columns.
+func (tablet *tabular) subtotal(g group, merged int) []string {
+	if !tablet.subtotals {
+		return nil
+	}
+
+	totals := make([]*rowset.Accumulator, len(tablet.columns))
+
+	for c, col := range tablet.columns {
+		if col.total == nil {
+			continue
+		}
+
+		totals[c] = rowset.NewAccumulator(col.total.Aggregate())
+
+		for r := g.start; r < g.end && r < len(tablet.cells); r++ {
+			if tablet.cells[r] != nil {
+				totals[c].Add(tablet.cells[r][c])
+			}
+		}
+	}
+
+	return tablet.totalsRow(totals, lpax.Sprintf(langpack.TextSubtotal), merged)
+}
+
+// mergeCells returns a copy of the row with the first merged cells blank.
+func mergeCells(row []string, merged int) []string {
+	if merged == 0 || row == nil {
+		return row
+	}
+
+	fields := append([]string{}, row...)
+	for i := 0; i < merged && i < len(fields); i++ {
+		fields[i] = ""
+	}
+	return fields
+}
+
+// writeGroups writes the groups of rows, each followed by its subtotals.
+// Grid styles merge the group columns cells down each group, other styles write a heading before each group.
+func (aw *alignedWriter) writeGroups(groups []group) error {
+	merged := 0
+	if aw.grid != nil {
+		merged = aw.tablet.mergedColumns()
+	}
+
+	defer func() { aw.merged = 0 }()
+
+	for i, g := range groups {
+		if aw.grid == nil {
+			if err := aw.writeHeading(g.title, i > 0); err != nil {
+				return err
+			}
+		}
+
+		for r := g.start; r < g.end; r++ {
+			aw.merged = 0
+			row := aw.tablet.rows[r]
+
+			if r > g.start {
+				aw.merged = merged
+				row = mergeCells(row, merged)
+			}
+
+			if err := aw.writeRow(row, aw.tablet.nested[r]); err != nil {
+				return err
+			}
+		}
+
+		if err := aw.writeTotals(g.totals, merged); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeHeading writes the heading of a group, separated from any previous group by a blank line.
+func (aw *alignedWriter) writeHeading(title string, separate bool) error {
+	if separate {
+		if _, err := aw.out.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+
+	_, err := aw.out.Write([]byte(aw.tablet.colors.headerColor().apply(title) + "\n"))
+	return err
+}
+
+// writeMarkdownGroups writes each group as a table headed by its title, the grand totals are the last row
+// of the final table.
+func (tablet *tabular) writeMarkdownGroups(out io.Writer, groups []group) error {
+	footer := tablet.footer()
+
+	for i, g := range groups {
+		title := "**" + g.title + "**\n\n"
+		if i > 0 {
+			title = "\n" + title
+		}
+
+		if _, err := out.Write([]byte(title)); err != nil {
+			return err
+		}
+
+		if err := tablet.writeMarkdownHeader(out); err != nil {
+			return err
+		}
+
+		for _, row := range tablet.rows[g.start:g.end] {
+			if err := tablet.writeMarkdownRow(out, row); err != nil {
+				return err
+			}
+		}
+
+		if err := tablet.writeMarkdownRow(out, g.totals); err != nil {
+			return err
+		}
+	}
+
+	return tablet.writeMarkdownRow(out, footer)
+}
+
+// writeMergedGridLine writes a grid line beneath all but the first merged columns, which continue down as merged cells.
+func writeMergedGridLine(out io.Writer, total int, spacing []int, merged int, line gridLine, vertical string) error {
+	if merged == 0 {
+		return writeGridLine(out, total, spacing, line)
+	}
+
+	var b strings.Builder
+	b.Grow(total)
+
+	b.WriteString(vertical)
+
+	for i, c := range spacing {
+		switch {
+		case i < merged-1:
+			b.WriteString(strings.Repeat(" ", c) + vertical)
+		case i == merged-1:
+			b.WriteString(strings.Repeat(" ", c) + line.left)
+		default:
+			b.WriteString(strings.Repeat(line.horizontal, c))
+			if i < len(spacing)-1 {
+				b.WriteString(line.cross)
+			}
+		}
+	}
+
+	b.WriteString(line.right)
+	b.WriteString("\n")
+
+	_, err := out.Write([]byte(b.String()))
+
+	return err
+}
@@ -0,0 +1,208 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+type capacityData struct {
+	Name   string
+	Status string
+	Zone   string
+	Size   int `tabular:",agg=sum"`
+}
+
+func capacityRows() []capacityData {
+	return []capacityData{
+		{Name: "a", Status: "ok", Zone: "x", Size: 1},
+		{Name: "b", Status: "failed", Zone: "y", Size: 2},
+		{Name: "c", Status: "ok", Zone: "y", Size: 3},
+		{Name: "d", Status: "ok", Zone: "x", Size: 4},
+	}
+}
+
+func groupOptions(style TableStyle) Options {
+	options := NewOptions()
+	options.Style = style
+	options.GroupBy = []string{"status"}
+	options.Subtotals = true
+	return options
+}
+
+func TestGroupsGrid(t *testing.T) {
+	got := formatStyle(t, groupOptions(Grid), capacityRows())
+
+	expected := `+--------+----------+------+------+
+| Status | Name     | Zone | Size |
++--------+----------+------+------+
+| ok     | a        | x    |    1 |
+|        +----------+------+------+
+|        | c        | y    |    3 |
+|        +----------+------+------+
+|        | d        | x    |    4 |
+|        +----------+------+------+
+|        | Subtotal |      |    8 |
++--------+----------+------+------+
+| failed | b        | y    |    2 |
+|        +----------+------+------+
+|        | Subtotal |      |    2 |
++--------+----------+------+------+
+| Total  |          |      |   10 |
++--------+----------+------+------+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestGroupsGridMultipleColumns(t *testing.T) {
+	options := groupOptions(DoubleLine)
+	options.GroupBy = []string{"Zone", "STATUS"}
+	options.Subtotals = false
+	options.ExcludeSet = map[string]bool{"size": true}
+
+	got := formatStyle(t, options, capacityRows())
+
+	expected := `╔══════╦════════╦══════╗
+║ Zone ║ Status ║ Name ║
+╠══════╬════════╬══════╣
+║ x    ║ ok     ║ a    ║
+║      ║        ╟──────╢
+║      ║        ║ d    ║
+╟──────╫────────╫──────╢
+║ y    ║ failed ║ b    ║
+╟──────╫────────╫──────╢
+║ y    ║ ok     ║ c    ║
+╚══════╩════════╩══════╝
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestGroupsAligned(t *testing.T) {
+	got := formatStyle(t, groupOptions(Aligned), capacityRows())
+
+	expected := `Name     Status Zone Size
+Status: ok
+a        ok     x       1
+c        ok     y       3
+d        ok     x       4
+Subtotal                8
+
+Status: failed
+b        failed y       2
+Subtotal                2
+Total                  10
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestGroupsLabelColumn(t *testing.T) {
+	options := groupOptions(Grid)
+	options.Columns = rowset.ParseColumnSpecs("Status,Size,Name")
+
+	got := formatStyle(t, options, capacityRows())
+
+	expected := `+--------+------+----------+
+| Status | Size | Name     |
++--------+------+----------+
+| ok     |    1 | a        |
+|        +------+----------+
+|        |    3 | c        |
+|        +------+----------+
+|        |    4 | d        |
+|        +------+----------+
+|        |    8 | Subtotal |
++--------+------+----------+
+| failed |    2 | b        |
+|        +------+----------+
+|        |    2 | Subtotal |
++--------+------+----------+
+| Total  |   10 |          |
++--------+------+----------+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestGroupsMarkdown(t *testing.T) {
+	options := groupOptions(Markdown)
+	options.ColumnSet = map[string]bool{"name": true, "status": true, "size": true}
+
+	got := formatStyle(t, options, capacityRows())
+
+	expected := `**Status: ok**
+
+|Name|Status|Size|
+|-|-|-|
+|a|ok|1|
+|c|ok|3|
+|d|ok|4|
+|Subtotal||8|
+
+**Status: failed**
+
+|Name|Status|Size|
+|-|-|-|
+|b|failed|2|
+|Subtotal||2|
+|Total||10|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestGroupsPlain(t *testing.T) {
+	got := formatPlain(t, groupOptions(Plain), capacityRows())
+
+	testsupport.CompareStrings(t, "Name,Status,Zone,Size\na,ok,x,1\nc,ok,y,3\nd,ok,x,4\nb,failed,y,2\n", got)
+}
+
+func TestGroupsSingleStruct(t *testing.T) {
+	got := formatPlain(t, groupOptions(Plain), capacityRows()[0])
+
+	testsupport.CompareStrings(t, "Name,Output\nName,a\nStatus,ok\nZone,x\nSize,1\n", got)
+}
+
+func TestGroupsStream(t *testing.T) {
+	options := groupOptions(Plain)
+	options.ColumnSeparator = ","
+
+	rows := capacityRows()
+	got := streamRows(t, options, rows[0], rows[1], rows[2])
+
+	testsupport.CompareStrings(t, "Name,Status,Zone,Size\na,ok,x,1\nb,failed,y,2\nc,ok,y,3\n", got)
+}
+
+func TestGroupsUnknownColumn(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := groupOptions(Grid)
+	options.GroupBy = []string{"Region"}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, options, capacityRows())
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorUnknownField, "Region") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
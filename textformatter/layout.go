@@ -0,0 +1,167 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"reflect"
+	"sync"
+)
+
+// layoutField is a field of a struct layout.  The fields of flattened structs follow their struct field
+// and refer back to it as their parent.
+type layoutField struct {
+	name       string
+	index      int
+	parent     int
+	layout     fieldLayout
+	nested     bool
+	isMap      bool
+	isBool     bool
+	rightAlign bool
+	numeric    bool
+	tagInfo    *tagData
+}
+
+// structLayout is the flattened list of fields output for a struct type.
+type structLayout struct {
+	fields []layoutField
+}
+
+// layouts caches the struct layout of each reflected type.
+var layouts sync.Map
+
+// layoutFor returns the struct layout of the struct type t, building and caching it on first use.
+func layoutFor(t reflect.Type) *structLayout {
+	if l, ok := layouts.Load(t); ok {
+		return l.(*structLayout)
+	}
+
+	actual, _ := layouts.LoadOrStore(t, newStructLayout(t))
+	return actual.(*structLayout)
+}
+
+// newStructLayout builds the struct layout of the struct type t.
+func newStructLayout(t reflect.Type) *structLayout {
+	l := &structLayout{}
+	l.add(t, -1, map[reflect.Type]bool{})
+	return l
+}
+
+// add appends the output fields of the struct type t, flattening any struct fields.
+func (l *structLayout) add(t reflect.Type, parent int, flattening map[reflect.Type]bool) {
+	flattening[t] = true
+	defer delete(flattening, t)
+
+	n := t.NumField()
+	for i := 0; i < n; i++ {
+		field := t.Field(i)
+		tagInfo := getTags(field.Tag, tabularTagName)
+		name := getFieldName(field.Name, tagInfo)
+		if name == "" || name == "-" {
+			continue
+		}
+
+		layout, structType := layoutOf(field.Type, flattening)
+		nested := layout == layoutNone && isNestedField(field.Type)
+		if layout == layoutNone && !nested {
+			continue
+		}
+
+		l.fields = append(l.fields, layoutField{
+			name:       name,
+			index:      i,
+			parent:     parent,
+			layout:     layout,
+			nested:     nested,
+			isMap:      field.Type.Kind() == reflect.Map,
+			isBool:     field.Type.Kind() == reflect.Bool,
+			rightAlign: isRightAligned(field.Type),
+			numeric:    isNumeric(field.Type),
+			tagInfo:    tagInfo,
+		})
+
+		if layout == layoutFlatten {
+			l.add(structType, len(l.fields)-1, flattening)
+		}
+	}
+}
+
+// selected returns which fields of the layout the table outputs, a field is output if it
+// and all its parents are.  The selection is held by the table for reuse.
+func (tablet *tabular) selected(l *structLayout) []bool {
+	if sel, ok := tablet.selections[l]; ok {
+		return sel
+	}
+
+	sel := make([]bool, len(l.fields))
+	for i, f := range l.fields {
+		sel[i] = tablet.shouldOutputColumn(f.name) && (f.parent < 0 || sel[f.parent])
+	}
+
+	if tablet.selections == nil {
+		tablet.selections = make(map[*structLayout][]bool)
+	}
+	tablet.selections[l] = sel
+
+	return sel
+}
+
+// visit calls fn for each field of the struct value selected by the table with the field value.
+// If the field is, or is within, a nil pointer to a flattened struct nilField is the outermost nil field.
+func (tablet *tabular) visit(value reflect.Value, fn func(f, nilField *layoutField, v reflect.Value) error) error {
+	l := layoutFor(value.Type())
+	sel := tablet.selected(l)
+
+	values := make([]reflect.Value, len(l.fields))
+	nilOf := make([]int, len(l.fields))
+
+	for i := range l.fields {
+		if !sel[i] {
+			continue
+		}
+
+		f := &l.fields[i]
+		nilOf[i] = -1
+
+		switch {
+		case f.parent < 0:
+			values[i] = value.Field(f.index)
+		case nilOf[f.parent] >= 0:
+			nilOf[i] = nilOf[f.parent]
+		default:
+			values[i] = values[f.parent].Field(f.index)
+		}
+
+		if f.layout == layoutFlatten && nilOf[i] < 0 {
+			values[i] = indirect(values[i])
+			if !values[i].IsValid() {
+				nilOf[i] = i
+			}
+		}
+
+		var nilField *layoutField
+		if nilOf[i] >= 0 {
+			nilField = &l.fields[nilOf[i]]
+		}
+
+		if err := fn(f, nilField, values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
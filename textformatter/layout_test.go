@@ -0,0 +1,195 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type benchEmbedded struct {
+	Zone  string `tabular:"Zone"`
+	Rack  int    `tabular:"Rack,width=6"`
+	Slot  *int
+	Owner string `tabular:"-"`
+}
+
+type benchRow struct {
+	Name     string        `tabular:"Name"`
+	Status   string        `tabular:"Status,color=green"`
+	Size     int64         `tabular:"Size,unit=bytes"`
+	Load     float64       `tabular:"Load,format=%.2f"`
+	Healthy  bool          `tabular:"Healthy,trueonly"`
+	Uptime   time.Duration `tabular:"Uptime,duration=short"`
+	Location benchEmbedded
+	Host     *benchEmbedded
+}
+
+func benchRows(n int) []benchRow {
+	slot := 4
+	rows := make([]benchRow, n)
+	for i := range rows {
+		rows[i] = benchRow{
+			Name: "service", Status: "ok", Size: int64(i) * 1024, Load: float64(i) / 3, Healthy: i%2 == 0,
+			Uptime: time.Duration(i) * time.Minute, Location: benchEmbedded{Zone: "a", Rack: i, Slot: &slot},
+		}
+	}
+	return rows
+}
+
+func TestLayoutFor(t *testing.T) {
+	l := layoutFor(reflect.TypeOf(benchRow{}))
+
+	if l != layoutFor(reflect.TypeOf(benchRow{})) {
+		t.Error("Layout not cached")
+	}
+
+	names := make([]string, len(l.fields))
+	for i, f := range l.fields {
+		names[i] = f.name
+		if f.parent >= 0 {
+			names[i] = l.fields[f.parent].name + "." + f.name
+		}
+	}
+
+	expected := "Name,Status,Size,Load,Healthy,Uptime,Location,Location.Zone,Location.Rack,Location.Slot," +
+		"Host,Host.Zone,Host.Rack,Host.Slot"
+	if got := strings.Join(names, ","); got != expected {
+		t.Errorf("Unexpected fields %s", got)
+	}
+}
+
+func TestLayoutSelected(t *testing.T) {
+	table := newTabular(nil, map[string]bool{"location": true, "rack": true})
+
+	l := layoutFor(reflect.TypeOf(benchRow{}))
+	sel := table.selected(l)
+
+	var names []string
+	for i, f := range l.fields {
+		if sel[i] {
+			names = append(names, f.name)
+		}
+	}
+
+	if got := strings.Join(names, ","); got != "Name,Status,Size,Load,Healthy,Uptime,Host,Zone,Slot" {
+		t.Errorf("Unexpected selection %s", got)
+	}
+}
+
+func TestLayoutConcurrent(t *testing.T) {
+	type concurrentRow struct {
+		Name string
+		Size int `tabular:",unit=bytes"`
+	}
+
+	rows := []concurrentRow{{Name: "a", Size: 2048}, {Name: "b", Size: 3}}
+	expected := "Name,Size\na,2.0 KiB\nb,3 B\n"
+
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			fmt, err := NewFormatter()
+			if err != nil {
+				t.Errorf("Error %v", err)
+				return
+			}
+
+			options := NewOptions()
+			options.Style = Plain
+			options.ColumnSeparator = ","
+
+			var buf bytes.Buffer
+			if err := fmt.Format(&buf, options, rows); err != nil {
+				t.Errorf("Formatter Error %v", err)
+			}
+			results[i] = buf.String()
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, got := range results {
+		if got != expected {
+			t.Errorf("Unexpected output %q", got)
+		}
+	}
+}
+
+func benchmarkFormat(b *testing.B, style TableStyle, data interface{}) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	options := NewOptions()
+	options.Style = style
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := fmt.Format(ioutil.Discard, options, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormatRowsPlain(b *testing.B) {
+	benchmarkFormat(b, Plain, benchRows(10000))
+}
+
+func BenchmarkFormatRowsAligned(b *testing.B) {
+	benchmarkFormat(b, Aligned, benchRows(10000))
+}
+
+func benchmarkLayouts(b *testing.B, layout func(reflect.Type) *structLayout) {
+	rows := benchRows(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := range rows {
+			if layout(reflect.TypeOf(rows[j])) == nil {
+				b.Fatal("no layout")
+			}
+		}
+	}
+}
+
+func BenchmarkLayoutRows(b *testing.B) {
+	benchmarkLayouts(b, layoutFor)
+}
+
+func BenchmarkLayoutRowsUncached(b *testing.B) {
+	benchmarkLayouts(b, newStructLayout)
+}
+
+func BenchmarkFormatDetail(b *testing.B) {
+	benchmarkFormat(b, Aligned, benchRows(1)[0])
+}
@@ -0,0 +1,303 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nehemming/yaff/rowset"
+)
+
+// mapKeyColumn is the name of the key column of a table of mapped structs.
+const mapKeyColumn = "Key"
+
+// indirect follows pointers and interfaces to the underlying value, nil values are invalid.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isStructType returns true if values of t, following pointers, are structs that are output as columns.
+// Structs that format their own text, such as time.Time, are output as values.
+func isStructType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !rowset.HasCellText(t)
+}
+
+// reflectMap outputs a map, maps of structs are output as a table with a key column
+// and other maps as a key value detail table.
+func reflectMap(ctx context.Context, table *tabular, value reflect.Value) error {
+	if value.Len() == 0 {
+		return nil
+	}
+
+	if isStructType(value.Type().Elem()) {
+		return reflectMapTable(ctx, table, value)
+	}
+
+	return reflectMapDetail(ctx, table, value)
+}
+
+// reflectMapDetail outputs the keys and values of a map as name value pairs, in key order.
+func reflectMapDetail(ctx context.Context, table *tabular, value reflect.Value) error {
+	_, _ = table.addColumn("Name", true, nil)
+	_, _ = table.addColumn("Output", false, nil)
+
+	names, values := make([]string, 0, value.Len()), make([]reflect.Value, 0, value.Len())
+
+	if len(table.columnSpecs) > 0 {
+		for _, spec := range table.columnSpecs {
+			names = append(names, spec.Header(spec.Field))
+			values = append(values, mapField(value, spec.Field))
+		}
+	} else {
+		for _, key := range rowset.SortedKeys(value) {
+			if name := fmt.Sprint(key.Interface()); table.shouldOutputColumn(name) {
+				names = append(names, name)
+				values = append(values, value.MapIndex(key))
+			}
+		}
+	}
+
+	for i, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		row := table.newRow()
+
+		if err := table.setField(row, colID(0), "%s", name); err != nil {
+			return err
+		}
+		if err := table.setField(row, colID(1), "%s", table.valueText(values[i], nil)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reflectMapTable outputs a map of structs as a table, with a row per key in key order.
+func reflectMapTable(ctx context.Context, table *tabular, value reflect.Value) error {
+	structType := value.Type().Elem()
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	if _, err := table.addColumn(mapKeyColumn, false, nil); err != nil {
+		return err
+	}
+
+	var err error
+	if len(table.columnSpecs) > 0 {
+		err = reflectSpecHeader(table, structType)
+	} else {
+		err = reflectStructHeader(table, reflect.New(structType).Elem())
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, key := range rowset.SortedKeys(value) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		row := table.newRow()
+		if err := table.setField(row, colID(0), "%v", key.Interface()); err != nil {
+			return err
+		}
+
+		item := indirect(value.MapIndex(key))
+		if !item.IsValid() {
+			continue
+		}
+
+		if len(table.columnSpecs) > 0 {
+			err = reflectSpecRow(table, row, colID(1), item)
+		} else {
+			err = reflectStructRow(table, row, colID(1), item)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isMapArray returns true if the items of an array are maps, or interfaces holding maps.
+func isMapArray(value reflect.Value) bool {
+	t := value.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+
+	case reflect.Interface:
+		found := false
+		n := value.Len()
+		for i := 0; i < n; i++ {
+			item := indirect(value.Index(i))
+			if !item.IsValid() {
+				continue
+			}
+			if item.Kind() != reflect.Map {
+				return false
+			}
+			found = true
+		}
+		return found
+
+	default:
+		return false
+	}
+}
+
+// reflectMapArray outputs an array of maps as a table whose columns are the union of the keys of the maps.
+func reflectMapArray(ctx context.Context, table *tabular, value reflect.Value, order []int) error {
+	items := make([]reflect.Value, len(order))
+	for i, index := range order {
+		items[i] = indirect(value.Index(index))
+	}
+
+	if err := reflectMapHeader(table, items...); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := reflectMapRow(table, table.newRow(), item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reflectMapHeader adds a column for each key in the items, in key order, or for each column spec.
+// Columns are right aligned when their first non nil value is not a string.
+func reflectMapHeader(table *tabular, items ...reflect.Value) error {
+	kinds := make(map[string]reflect.Kind)
+	names := make([]string, 0, 8)
+
+	for _, item := range items {
+		if !item.IsValid() || item.Kind() != reflect.Map {
+			continue
+		}
+
+		iter := item.MapRange()
+		for iter.Next() {
+			name := fmt.Sprint(iter.Key().Interface())
+
+			kind, seen := kinds[name]
+			if !seen {
+				names = append(names, name)
+			}
+
+			if v := indirect(iter.Value()); kind == reflect.Invalid && v.IsValid() {
+				kind = v.Kind()
+			}
+			kinds[name] = kind
+		}
+	}
+
+	sort.Strings(names)
+
+	headers := names
+	if len(table.columnSpecs) > 0 {
+		names, headers = make([]string, 0, len(table.columnSpecs)), make([]string, 0, len(table.columnSpecs))
+		for _, spec := range table.columnSpecs {
+			names = append(names, spec.Field)
+			headers = append(headers, spec.Header(spec.Field))
+
+			// Spec fields match keys ignoring case
+			if _, ok := kinds[spec.Field]; !ok {
+				for name, kind := range kinds {
+					if strings.EqualFold(name, spec.Field) && kind != reflect.Invalid {
+						kinds[spec.Field] = kind
+					}
+				}
+			}
+		}
+	}
+
+	for i, name := range names {
+		if len(table.columnSpecs) == 0 && !table.shouldOutputColumn(name) {
+			continue
+		}
+
+		kind := kinds[name]
+		if _, err := table.addColumn(headers[i], kind != reflect.String && kind != reflect.Invalid, nil); err != nil {
+			return err
+		}
+
+		table.mapKeys = append(table.mapKeys, name)
+	}
+
+	return nil
+}
+
+// reflectMapRow sets the fields of a row from the keys of a map.
+func reflectMapRow(table *tabular, row rowID, item reflect.Value) error {
+	for i, key := range table.mapKeys {
+		var v reflect.Value
+		if item.IsValid() && item.Kind() == reflect.Map {
+			v = mapField(item, key)
+		}
+
+		if err := table.setCell(row, colID(i), v, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapField returns the value of a key in a map, matching keys by their text.
+func mapField(item reflect.Value, key string) reflect.Value {
+	if rowset.IsKeyed(item.Type()) {
+		v, _ := rowset.MapValue(item, key)
+		return v
+	}
+
+	iter := item.MapRange()
+	for iter.Next() {
+		if fmt.Sprint(iter.Key().Interface()) == key {
+			return iter.Value()
+		}
+	}
+
+	return reflect.Value{}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff/rowset"
+)
+
+func formatPlain(t *testing.T, options Options, data interface{}) string {
+	t.Helper()
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options.Style = Plain
+	options.ColumnSeparator = ","
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestMapDetail(t *testing.T) {
+	got := formatPlain(t, NewOptions(), map[string]interface{}{
+		"zeta":  1,
+		"alpha": "one",
+		"empty": nil,
+	})
+
+	expected := `Name,Output
+alpha,one
+empty,
+zeta,1
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMapDetailNumericKeys(t *testing.T) {
+	got := formatPlain(t, NewOptions(), map[int]string{10: "ten", 2: "two", 1: "one"})
+
+	expected := `Name,Output
+1,one
+2,two
+10,ten
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMapOfStructs(t *testing.T) {
+	got := formatPlain(t, NewOptions(), map[string]*testData2{
+		"b": {S: "Bye", B1: true},
+		"a": {S: "Hello", B2: true},
+		"c": nil,
+	})
+
+	expected := `Key,S,B1,B2
+a,Hello,false,true
+b,Bye,true,
+c,,,
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMapOfStructsColumns(t *testing.T) {
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("S as Text")
+
+	got := formatPlain(t, options, map[string]testData2{"b": {S: "Bye"}, "a": {S: "Hello"}})
+
+	expected := `Key,Text
+a,Hello
+b,Bye
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMapArrayDecodedJSON(t *testing.T) {
+	var data interface{}
+
+	err := json.Unmarshal([]byte(`[{"name":"one","size":1},{"size":22,"status":"ok"},null]`), &data)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	got := formatPlain(t, NewOptions(), data)
+
+	expected := `name,size,status
+one,1,
+,22,ok
+,,
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMapArrayOptions(t *testing.T) {
+	options := NewOptions()
+	options.ExcludeSet = map[string]bool{"Status": true}
+	options.SortBy = rowset.ParseSortKeys("-size")
+
+	got := formatPlain(t, options, []map[string]interface{}{
+		{"name": "one", "size": 1},
+		{"name": "two", "size": 22, "status": "ok"},
+	})
+
+	expected := `name,size
+two,22
+one,1
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMapArrayColumnsAligned(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("Size as Bytes,NAME")
+
+	var buf bytes.Buffer
+	err = fmt.Format(&buf, options, []map[string]interface{}{
+		{"name": "one", "size": 1},
+		{"name": "three", "size": 333},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `Bytes NAME 
+    1 one  
+  333 three
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+type mapFieldData struct {
+	Name   string
+	Labels map[string]string
+}
+
+func TestMapFieldDetail(t *testing.T) {
+	got := formatPlain(t, NewOptions(), mapFieldData{Name: "svc", Labels: map[string]string{"tier": "web", "env": "prod"}})
+
+	expected := `Name,Output
+Name,svc
+Labels.env,prod
+Labels.tier,web
+`
+	testsupport.CompareStrings(t, expected, got)
+}
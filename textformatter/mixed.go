@@ -0,0 +1,226 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// mixedType is a concrete element type of a mixed array.
+type mixedType struct {
+	t reflect.Type
+	// columns maps the column fields of a struct type to their table column.
+	columns map[*layoutField]colID
+	// specs are the column specs resolved against a struct type, has is false for fields it lacks.
+	specs []specColumn
+	has   []bool
+}
+
+// mixedArray holds the columns of an array of interface elements, the union of the columns of
+// each concrete element type in the order first seen.
+type mixedArray struct {
+	table *tabular
+	types map[reflect.Type]*mixedType
+	// output is the column of elements that are not structs, -1 if there is none.
+	output colID
+}
+
+// isMixedArray returns true if the elements of the array are interfaces, whose concrete types can differ.
+func isMixedArray(value reflect.Value) bool {
+	t := value.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Interface
+}
+
+// isRecord returns true if the element is output as a row of struct fields.
+func isRecord(item reflect.Value) bool {
+	return item.Kind() == reflect.Struct && !isCellValue(item.Type())
+}
+
+// reflectMixedArray outputs an array of interface elements, nil elements are skipped.
+// Cells are left empty where the type of an element lacks a column.
+func reflectMixedArray(ctx context.Context, table *tabular, value reflect.Value, order []int) error {
+	items := make([]reflect.Value, 0, len(order))
+	for _, index := range order {
+		if item := indirect(value.Index(index)); item.IsValid() {
+			items = append(items, item)
+		}
+	}
+
+	m := &mixedArray{table: table, types: make(map[reflect.Type]*mixedType), output: -1}
+
+	if err := m.addColumns(items); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := m.addRow(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumns adds the columns of each element type in the order first seen, or the columns of the
+// column specs when set.
+func (m *mixedArray) addColumns(items []reflect.Value) error {
+	types := make([]*mixedType, 0, 4)
+	scalars := false
+
+	for _, item := range items {
+		if !isRecord(item) {
+			// Other values share a single Output column
+			if !scalars && len(m.table.columnSpecs) == 0 {
+				scalars = true
+				types = append(types, nil)
+			}
+			continue
+		}
+
+		if _, ok := m.types[item.Type()]; !ok {
+			mt := &mixedType{t: item.Type()}
+			m.types[mt.t] = mt
+			types = append(types, mt)
+		}
+	}
+
+	if len(m.table.columnSpecs) > 0 {
+		return m.addSpecColumns(types)
+	}
+
+	names := make(map[string]colID)
+
+	for _, mt := range types {
+		if mt == nil {
+			col, err := m.table.addColumn("Output", false, nil)
+			if err != nil {
+				return err
+			}
+			m.output = col
+			continue
+		}
+
+		l := layoutFor(mt.t)
+		sel := m.table.selected(l)
+		mt.columns = make(map[*layoutField]colID)
+
+		for i := range l.fields {
+			f := &l.fields[i]
+			if !sel[i] || f.layout != layoutColumn {
+				continue
+			}
+
+			col, ok := names[f.name]
+			if !ok {
+				var err error
+				if col, err = m.table.addFieldColumn(f.name, f.rightAlign, f.numeric, f.tagInfo); err != nil {
+					return err
+				}
+				names[f.name] = col
+			}
+
+			mt.columns[f] = col
+		}
+	}
+
+	return nil
+}
+
+// addSpecColumns adds a column for each column spec, described by the first type with the spec field.
+func (m *mixedArray) addSpecColumns(types []*mixedType) error {
+	specs := m.table.columnSpecs
+
+	for _, mt := range types {
+		mt.specs = make([]specColumn, len(specs))
+		mt.has = make([]bool, len(specs))
+
+		for i, spec := range specs {
+			mt.specs[i], mt.has[i] = resolveSpec(mt.t, spec)
+		}
+	}
+
+	for i, spec := range specs {
+		found := false
+
+		for _, mt := range types {
+			if mt.has[i] {
+				c := mt.specs[i]
+				if _, err := m.table.addFieldColumn(c.name, c.rightAlign, c.numeric, c.tagInfo); err != nil {
+					return err
+				}
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return lpax.Errorf(langpack.ErrorUnknownField, spec.Field)
+		}
+	}
+
+	return nil
+}
+
+// addRow adds an element as a row, setting the cells of the columns its type has.
+func (m *mixedArray) addRow(item reflect.Value) error {
+	table := m.table
+
+	if !isRecord(item) {
+		if m.output < 0 {
+			return nil
+		}
+
+		return table.setCell(table.newRow(), m.output, item, nil)
+	}
+
+	mt := m.types[item.Type()]
+	row := table.newRow()
+
+	if len(table.columnSpecs) > 0 {
+		for i, c := range mt.specs {
+			if !mt.has[i] {
+				continue
+			}
+
+			if err := reflectSpecCell(table, row, colID(i), item, c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return table.visit(item, func(f, nilField *layoutField, v reflect.Value) error {
+		// Flattened and nested fields have no column of their own
+		col := mt.columns[f]
+
+		_, err := reflectFieldValue(table, row, col, f, nilField, v)
+		return err
+	})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+type shape interface {
+	Area() float64
+}
+
+type square struct {
+	Name string
+	Side float64
+}
+
+func (s square) Area() float64 { return s.Side * s.Side }
+
+type rectangle struct {
+	Name   string
+	Width  float64
+	Height float64
+}
+
+func (r *rectangle) Area() float64 { return r.Width * r.Height }
+
+func shapes() []shape {
+	return []shape{
+		square{Name: "a", Side: 2},
+		&rectangle{Name: "b", Width: 3, Height: 4},
+		nil,
+		square{Name: "c", Side: 1},
+	}
+}
+
+func TestMixedArray(t *testing.T) {
+	got := formatPlain(t, NewOptions(), shapes())
+
+	testsupport.CompareStrings(t, "Name,Side,Width,Height\na,2,,\nb,,3,4\nc,1,,\n", got)
+}
+
+func TestMixedArrayInterfaces(t *testing.T) {
+	options := NewOptions()
+	options.ExcludeSet = map[string]bool{"height": true}
+
+	got := formatPlain(t, options, []interface{}{"text", &rectangle{Name: "b", Width: 3, Height: 4}, 5})
+
+	testsupport.CompareStrings(t, "Output,Name,Width\ntext,,\n,b,3\n5,,\n", got)
+}
+
+func TestMixedArrayAligned(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+
+	got := formatStyle(t, options, shapes()[:2])
+
+	expected := `+------+------+-------+--------+
+| Name | Side | Width | Height |
++------+------+-------+--------+
+| a    |    2 |       |        |
++------+------+-------+--------+
+| b    |      |     3 |      4 |
++------+------+-------+--------+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestMixedArrayColumns(t *testing.T) {
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("Height as H,Name")
+
+	got := formatPlain(t, options, shapes())
+
+	testsupport.CompareStrings(t, "H,Name\n,a\n4,b\n,c\n", got)
+}
+
+func TestMixedArrayUnknownColumn(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("Depth")
+
+	err := fmt.Format(ioutil.Discard, options, shapes())
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorUnknownField, "Depth") {
+		t.Errorf("Error %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// nestedIndent indents nested tables written beneath their parent row.
+const nestedIndent = "    "
+
+// nestedTable is a table of the items of a slice of structs field.
+type nestedTable struct {
+	title string
+	table *tabular
+}
+
+// isNestedField returns true if a field of type t is a slice or array of structs.
+func isNestedField(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && isStructType(t.Elem())
+}
+
+// newNested reflects the items of a slice of structs into a nested table.
+// Nested tables use the options of their parent without any sorting, column selection, grouping, paging or aggregate options,
+// ok is false if the nesting depth has been reached or there are no items.
+func (tablet *tabular) newNested(title string, value reflect.Value) (nestedTable, bool, error) {
+	if tablet.options.NestedDepth < 1 || value.Len() == 0 {
+		return nestedTable{}, false, nil
+	}
+
+	options := tablet.options
+	options.NestedDepth--
+	options.ColumnSet = nil
+	options.Columns = nil
+	options.SortBy = nil
+	options.Aggregates = nil
+	options.GroupBy = nil
+	options.Offset, options.Limit, options.PageSize = 0, 0, 0
+
+	table := newStyledTabular(options)
+
+	// The parent rows check the context, nested tables are reflected with their row
+	if err := reflectArray(context.Background(), table, value); err != nil {
+		return nestedTable{}, false, err
+	}
+
+	return nestedTable{title: title, table: table}, true, nil
+}
+
+// addNested adds a nested table written beneath a row.
+func (tablet *tabular) addNested(row rowID, title string, value reflect.Value) error {
+	nested, ok, err := tablet.newNested(title, value)
+	if ok {
+		tablet.nested[row] = append(tablet.nested[row], nested)
+	}
+	return err
+}
+
+// addSection adds a nested table written in a titled section after the table.
+func (tablet *tabular) addSection(title string, value reflect.Value) error {
+	nested, ok, err := tablet.newNested(title, value)
+	if ok {
+		tablet.sections = append(tablet.sections, nested)
+	}
+	return err
+}
+
+// rowSections returns the nested tables of the rows as sections titled with their row number,
+// first is the number of rows output before the rows held by the table.
+func (tablet *tabular) rowSections(first int) []nestedTable {
+	var sections []nestedTable
+
+	for i, nested := range tablet.nested {
+		sections = append(sections, titledSections(nested, first+i+1)...)
+	}
+
+	return sections
+}
+
+// titledSections returns the nested tables of a row as sections titled with the row number.
+func titledSections(nested []nestedTable, row int) []nestedTable {
+	sections := make([]nestedTable, len(nested))
+	for i, nt := range nested {
+		sections[i] = nestedTable{
+			title: fmt.Sprintf("%s (row %d)", nt.title, row),
+			table: nt.table,
+		}
+	}
+	return sections
+}
+
+// writeNested writes nested tables indented beneath their row.
+func writeNested(out io.Writer, nested []nestedTable, style TableStyle, terminalWidth int) error {
+	for _, nt := range nested {
+		if err := nt.write(out, nestedIndent, nt.title+":\n", style, terminalWidth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSections writes the titled sections following a table.
+// Markdown tables cannot hold nested tables so row nested tables are written as sections too.
+func (tablet *tabular) writeSections(out io.Writer, style TableStyle, terminalWidth int) error {
+	sections := tablet.sections
+	if style == Markdown {
+		sections = append(sections, tablet.rowSections(0)...)
+	}
+
+	return writeSectionList(out, sections, style, terminalWidth)
+}
+
+// writeSectionList writes titled sections.
+func writeSectionList(out io.Writer, sections []nestedTable, style TableStyle, terminalWidth int) error {
+	for _, nt := range sections {
+		title := "\n" + nt.title + ":\n"
+		if style == Markdown {
+			title = "\n**" + nt.title + "**\n\n"
+		}
+
+		if err := nt.write(out, "", title, style, terminalWidth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write writes the title and table, indenting each line.
+func (nt nestedTable) write(out io.Writer, indent, title string, style TableStyle, terminalWidth int) error {
+	if terminalWidth > 0 {
+		terminalWidth -= displayWidth(indent)
+		if terminalWidth < 1 {
+			terminalWidth = 1
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(title)
+
+	if err := nt.table.write(&buf, style, false, "", terminalWidth); err != nil {
+		return err
+	}
+
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "\n" && line != "" {
+			line = indent + line
+		}
+
+		if _, err := io.WriteString(out, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
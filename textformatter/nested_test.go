@@ -0,0 +1,214 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+)
+
+type childData struct {
+	Name  string
+	Parts []*childData
+}
+
+type parentData struct {
+	ID       int
+	Children []childData
+}
+
+func nestedRows() []parentData {
+	return []parentData{
+		{ID: 1, Children: []childData{{Name: "a", Parts: []*childData{{Name: "x"}}}, {Name: "bb"}}},
+		{ID: 2},
+	}
+}
+
+func formatNested(t *testing.T, style TableStyle, depth int, data interface{}) string {
+	t.Helper()
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Style = style
+	options.NestedDepth = depth
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestNestedGrid(t *testing.T) {
+	got := formatNested(t, Grid, 2, nestedRows())
+
+	expected := `+----+
+| ID |
++----+
+|  1 |
+    Children:
+    +------+
+    | Name |
+    +------+
+    | a    |
+        Parts:
+        +------+
+        | Name |
+        +------+
+        | x    |
+        +------+
+    +------+
+    | bb   |
+    +------+
++----+
+|  2 |
++----+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestNestedDepth(t *testing.T) {
+	got := formatNested(t, Aligned, 1, nestedRows())
+
+	expected := `ID
+ 1
+    Children:
+    Name
+    a   
+    bb  
+ 2
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestNestedDisabled(t *testing.T) {
+	got := formatNested(t, Aligned, 0, nestedRows())
+
+	testsupport.CompareStrings(t, "ID\n 1\n 2\n", got)
+}
+
+func TestNestedMarkdown(t *testing.T) {
+	got := formatNested(t, Markdown, 1, nestedRows())
+
+	expected := `|ID|
+|-|
+|1|
+|2|
+
+**Children (row 1)**
+
+|Name|
+|-|
+|a|
+|bb|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestNestedDetailSection(t *testing.T) {
+	got := formatNested(t, Aligned, 1, nestedRows()[0])
+
+	expected := `Name Output
+  ID 1     
+
+Children:
+Name
+a   
+bb  
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestNestedStreamMarkdown(t *testing.T) {
+	options := NewOptions()
+	options.Style = Markdown
+	options.NestedDepth = 1
+
+	rows := nestedRows()
+	got := streamRows(t, options, rows[1], rows[0])
+
+	expected := `|ID|
+|-|
+|2|
+|1|
+
+**Children (row 2)**
+
+|Name|
+|-|
+|a|
+|bb|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestNestedStreamMarkdownRestarts(t *testing.T) {
+	options := NewOptions()
+	options.Style = Markdown
+	options.NestedDepth = 1
+
+	got := streamRows(t, options, nestedRows()[0], nestedRows()[1])
+
+	expected := `|ID|
+|-|
+|1|
+
+**Children (row 1)**
+
+|Name|
+|-|
+|a|
+|bb|
+
+|ID|
+|-|
+|2|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestNestedStreamGrid(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+	options.NestedDepth = 1
+	options.StreamSampleRows = 1
+
+	got := streamRows(t, options, nestedRows()[0])
+
+	expected := `+----+
+| ID |
++----+
+|  1 |
+    Children:
+    +------+
+    | Name |
+    +------+
+    | a    |
+    +------+
+    | bb   |
+    +------+
++----+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
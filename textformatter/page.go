@@ -0,0 +1,82 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"io"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// pageRows returns the part of the row order within the table page, setting the trailer when paging.
+func (tablet *tabular) pageRows(order []int) []int {
+	if !tablet.page.IsSet() {
+		return order
+	}
+
+	start, end := tablet.page.Window(len(order))
+	tablet.setTrailer(start, end, len(order))
+
+	return order[start:end]
+}
+
+// setTrailer sets the trailer describing the rows shown from start to end of total rows.
+func (tablet *tabular) setTrailer(start, end, total int) {
+	if start < end {
+		tablet.trailer = lpax.Sprintf(langpack.TextShowingRows, start+1, end, total)
+	} else {
+		tablet.trailer = lpax.Sprintf(langpack.TextShowingNoRows, total)
+	}
+}
+
+// writeTrailer writes the trailer beneath the table, plain output has no trailer.
+func (tablet *tabular) writeTrailer(out io.Writer, style TableStyle) error {
+	if tablet.trailer == "" || style == Plain {
+		return nil
+	}
+
+	text := tablet.trailer + "\n"
+	if style == Markdown {
+		text = "\n" + text
+	}
+
+	_, err := out.Write([]byte(text))
+
+	return err
+}
+
+// repeatHeader writes the header again at the start of a new page of rows.
+func (aw *alignedWriter) repeatHeader() error {
+	if aw.grid == nil {
+		if _, err := aw.out.Write([]byte("\n")); err != nil {
+			return err
+		}
+
+		return aw.tablet.writeAlignedHeader(aw.out, nil, aw.pad, aw.totalSpacing)
+	}
+
+	if err := writeGridLine(aw.out, aw.totalSpacing, aw.spacing, aw.grid.header); err != nil {
+		return err
+	}
+
+	if err := aw.tablet.writeAlignedHeader(aw.out, aw.grid, aw.pad, aw.totalSpacing); err != nil {
+		return err
+	}
+
+	return writeGridLine(aw.out, aw.totalSpacing, aw.spacing, aw.grid.header)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff/rowset"
+)
+
+type pageData struct {
+	Name string
+	Size int
+}
+
+func pageRows() []pageData {
+	return []pageData{{"a", 1}, {"b", 2}, {"c", 3}, {"d", 4}, {"e", 5}}
+}
+
+func TestPageAligned(t *testing.T) {
+	options := NewOptions()
+	options.Offset = 1
+	options.Limit = 3
+	options.PageSize = 2
+
+	got := formatStyle(t, options, pageRows())
+
+	expected := `Name Size
+b       2
+c       3
+
+Name Size
+d       4
+Showing rows 2 to 4 of 5
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestPageGridSorted(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+	options.SortBy = []rowset.SortKey{{Column: "Size", Descending: true}}
+	options.Limit = 3
+	options.PageSize = 2
+
+	got := formatStyle(t, options, pageRows())
+
+	expected := `+------+------+
+| Name | Size |
++------+------+
+| e    |    5 |
++------+------+
+| d    |    4 |
++------+------+
+| Name | Size |
++------+------+
+| c    |    3 |
++------+------+
+Showing rows 1 to 3 of 5
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestPagePastEnd(t *testing.T) {
+	options := NewOptions()
+	options.Style = Markdown
+	options.Offset = 10
+
+	got := formatStyle(t, options, pageRows())
+
+	testsupport.CompareStrings(t, "\nShowing 0 of 5 rows\n", got)
+}
+
+func TestPagePlainHasNoTrailer(t *testing.T) {
+	options := NewOptions()
+	options.Offset = 3
+	options.Limit = 1
+
+	got := formatPlain(t, options, pageRows())
+
+	testsupport.CompareStrings(t, "Name,Size\nd,4\n", got)
+}
+
+func TestPageStream(t *testing.T) {
+	options := NewOptions()
+	options.Offset = 1
+	options.Limit = 2
+
+	rows := pageRows()
+	got := streamRows(t, options, rows[0], rows[1], rows[2], rows[3])
+
+	expected := `Name Size
+b       2
+c       3
+Showing rows 2 to 3 of 4
+`
+	testsupport.CompareStrings(t, expected, got)
+}
@@ -17,104 +17,111 @@ limitations under the License.
 package textformatter
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
 	"unicode"
+
+	"github.com/nehemming/yaff/rowset"
 )
 
 // wrapParamName is the name of the width param used to support wrapping text.
 const widthParamName = "width"
 
-func reflectInterface(table *tabular, value reflect.Value) error {
+func reflectInterface(ctx context.Context, table *tabular, value reflect.Value) error {
 	// If interface is to a pointer de reference it
 	if value.Kind() == reflect.Ptr {
 		value = reflect.Indirect(value)
 	}
 
+	// Values that format their own text are output as is
+	if value.IsValid() && isCellValue(value.Type()) {
+		return reflectOutputItem(table, value, true)
+	}
+
 	// Determin output type (arrays are tabular) a struct will display as detail
 	switch value.Kind() {
 	case reflect.Array, reflect.Slice:
-		return reflectArray(table, value)
+		return reflectArray(ctx, table, value)
 	case reflect.Struct:
-		return reflectStructDetail(table, value)
+		if len(table.columnSpecs) > 0 {
+			return reflectSpecDetail(ctx, table, value)
+		}
+		return reflectStructDetail(ctx, table, value)
+	case reflect.Map:
+		return reflectMap(ctx, table, value)
 	case reflect.Func:
 		return nil
 	default:
-		col, err := table.addColumn("Output", false, "")
-		if err != nil {
-			return err
-		}
-		return table.setField(table.newRow(), col, "%v", value.Interface())
+		return reflectOutputItem(table, value, true)
 	}
 }
 
-func reflectStructDetail(table *tabular, value reflect.Value) error {
+func reflectStructDetail(ctx context.Context, table *tabular, value reflect.Value) error {
 	// Validate this is a struct
 	if value.Kind() != reflect.Struct {
 		panic("unexpected type")
 	}
 
 	// Find num of fields in struct to iterate over
-	n := value.NumField()
-	if n == 0 {
+	if value.NumField() == 0 {
 		return nil
 	}
 
 	// Add 2 detail columns for field name and value
-	_, _ = table.addColumn("Name", true, "")
-	_, _ = table.addColumn("Output", false, "")
+	_, _ = table.addColumn("Name", true, nil)
+	_, _ = table.addColumn("Output", false, nil)
 
 	// Iterate over the structure
-	for i := 0; i < n; i++ {
-		if err := reflectFieldNameValue(table, value.Type().Field(i), value.Field(i)); err != nil {
+	return table.visit(value, func(f, nilField *layoutField, v reflect.Value) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-	}
 
-	return nil
+		return reflectFieldNameValue(table, f, nilField, v)
+	})
 }
 
-func reflectFieldNameValue(table *tabular, t reflect.StructField, value reflect.Value) error {
-	// Get tags
-	name := getFieldName(t.Name, getTags(t.Tag, tabularTagName))
-	if !table.shouldOutputColumn(name) {
-		return nil
-	}
-
-	// Kind is the declared kind so values of interfaces and pointers are excluded
-	kind := value.Kind()
+func reflectFieldNameValue(table *tabular, f, nilField *layoutField, value reflect.Value) error {
+	switch {
+	case nilField == f:
+		// A nil struct pointer is output as a single row
+		return table.addDetailRow(f.name, table.nilText(f.tagInfo))
 
-	switch kind {
-	case reflect.Array, reflect.Slice, reflect.Func, reflect.Map, reflect.Interface, reflect.Ptr:
+	case nilField != nil, f.layout == layoutFlatten:
 		return nil
 
-	case reflect.Struct:
-		n := value.NumField()
-		if n == 0 {
-			return nil
-		}
+	case f.nested:
+		return table.addSection(f.name, value)
 
-		for i := 0; i < n; i++ {
-			if err := reflectFieldNameValue(table, value.Type().Field(i), value.Field(i)); err != nil {
+	case f.isMap:
+		// Each key is output as a name qualified by the field name
+		for _, key := range rowset.SortedKeys(value) {
+			if err := table.addDetailRow(fmt.Sprintf("%s.%v", f.name, key.Interface()),
+				table.valueText(value.MapIndex(key), f.tagInfo)); err != nil {
 				return err
 			}
 		}
+		return nil
 
 	default:
-		row := table.newRow()
+		return table.addDetailRow(f.name, table.valueText(value, f.tagInfo))
+	}
+}
 
-		if err := table.setField(row, colID(0), "%s", name); err != nil {
-			return err
-		}
-		if err := table.setField(row, colID(1), "%v", value.Interface()); err != nil {
-			return err
-		}
+// addDetailRow adds a name value pair to a detail table.
+func (tablet *tabular) addDetailRow(name, text string) error {
+	row := tablet.newRow()
+
+	if err := tablet.setField(row, colID(0), "%s", name); err != nil {
+		return err
 	}
-	return nil
+
+	return tablet.setField(row, colID(1), "%s", text)
 }
 
-func reflectArray(table *tabular, value reflect.Value) error {
+func reflectArray(ctx context.Context, table *tabular, value reflect.Value) error {
 	// Check array or slice
 	k := value.Kind()
 	if k != reflect.Array && k != reflect.Slice {
@@ -123,60 +130,105 @@ func reflectArray(table *tabular, value reflect.Value) error {
 
 	// How big is the array
 	n := value.Len()
+	table.isArray = true
 
 	if n == 0 {
 		return nil
 	}
 
-	var col colID
-	var err error
+	// Visit the items in sorted order when sorting
+	order, err := table.rowOrder(value)
+	if err != nil {
+		return err
+	}
 
-	for i := 0; i < n; i++ {
-		// Get the array item
-		item := value.Index(i)
+	order = table.pageRows(order)
 
-		// Dereference pointers
-		if item.Kind() == reflect.Ptr {
-			item = reflect.Indirect(item)
+	// Maps are output with a column per key
+	if isMapArray(value) {
+		return reflectMapArray(ctx, table, value, order)
+	}
+
+	// Interface elements can be of different types
+	if isMixedArray(value) {
+		return reflectMixedArray(ctx, table, value, order)
+	}
+
+	for i, index := range order {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := reflectArrayItem(table, value.Index(index), i == 0); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+// reflectArrayItem adds an array item as a row of the table, first is set for the
+// first item where the table header is added.
+func reflectArrayItem(table *tabular, item reflect.Value, first bool) error {
+	// Dereference pointers
+	if item.Kind() == reflect.Ptr {
+		item = reflect.Indirect(item)
+	}
 
-		// Support struct ort or simple value types
-		switch item.Kind() {
-		case reflect.Struct:
-			// row of data
-			if i == 0 {
-				if err = reflectStructHeader(table, item); err != nil {
+	// Values that format their own text are output as is
+	if item.IsValid() && isCellValue(item.Type()) {
+		return reflectOutputItem(table, item, first)
+	}
+
+	// Support struct ort or simple value types
+	switch item.Kind() {
+	case reflect.Struct:
+		if len(table.columnSpecs) > 0 {
+			if first {
+				if err := reflectSpecHeader(table, item.Type()); err != nil {
 					return err
 				}
 			}
 
-			row := table.newRow()
+			return reflectSpecRow(table, table.newRow(), colID(0), item)
+		}
 
-			if err = reflectStructRow(table, row, item); err != nil {
+		// row of data
+		if first {
+			if err := reflectStructHeader(table, item); err != nil {
 				return err
 			}
+		}
 
-		case reflect.Array, reflect.Slice, reflect.Map, reflect.Func, reflect.Ptr, reflect.Interface:
-			continue
+		return reflectStructRow(table, table.newRow(), colID(0), item)
 
-		default:
-			// Output column data
-			if i == 0 {
-				col, err = table.addColumn("Output", false, "")
-				if err != nil {
-					return err
-				}
+	case reflect.Map:
+		if first {
+			if err := reflectMapHeader(table, item); err != nil {
+				return err
 			}
+		}
 
-			row := table.newRow()
+		return reflectMapRow(table, table.newRow(), item)
 
-			if err = table.setField(row, col, "%v", item.Interface()); err != nil {
-				return err
-			}
+	case reflect.Array, reflect.Slice, reflect.Func, reflect.Ptr, reflect.Interface, reflect.Invalid:
+		return nil
+
+	default:
+		return reflectOutputItem(table, item, first)
+	}
+}
+
+// reflectOutputItem adds a value as a row of a single Output column, first is set for the
+// first item where the column is added.
+func reflectOutputItem(table *tabular, item reflect.Value, first bool) error {
+	if first {
+		if _, err := table.addColumn("Output", false, nil); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return table.setCell(table.newRow(), colID(0), item, nil)
 }
 
 func reflectStructHeader(table *tabular, value reflect.Value) error {
@@ -185,11 +237,15 @@ func reflectStructHeader(table *tabular, value reflect.Value) error {
 		panic("unexpected type")
 	}
 
-	// Iterate ver a struct to get the files in the type
-	n := value.NumField()
-	for i := 0; i < n; i++ {
-		if err := reflectFieldHead(table, value.Type().Field(i)); err != nil {
-			return err
+	// Add a column for each of the selected fields of the type
+	l := layoutFor(value.Type())
+	sel := table.selected(l)
+
+	for i := range l.fields {
+		if sel[i] {
+			if err := reflectFieldHead(table, &l.fields[i]); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -200,6 +256,7 @@ type tagData struct {
 	Name    string
 	Options map[string]bool
 	Params  map[string]string
+	format  *valueFormat
 }
 
 func (tagData *tagData) getWidthParam() string {
@@ -240,6 +297,8 @@ func parseTags(tagEntry string) *tagData {
 		}
 	}
 
+	d.format = newValueFormat(d.Options, d.Params)
+
 	return &d
 }
 
@@ -260,101 +319,50 @@ func getFieldName(name string, tagInfo *tagData) string {
 	return name
 }
 
-func reflectFieldHead(table *tabular, t reflect.StructField) error {
-	tagData := getTags(t.Tag, tabularTagName)
-	name := getFieldName(t.Name, tagData)
-	if !table.shouldOutputColumn(name) {
+func reflectFieldHead(table *tabular, f *layoutField) error {
+	if f.layout != layoutColumn {
 		return nil
 	}
 
-	switch t.Type.Kind() {
-	case reflect.Array, reflect.Slice, reflect.Func, reflect.Map, reflect.Ptr, reflect.Interface:
-		return nil
-
-	case reflect.Struct:
-
-		// Embedded structure, flatten struct
-		n := t.Type.NumField()
-		for i := 0; i < n; i++ {
-			if err := reflectFieldHead(table, t.Type.Field(i)); err != nil {
-				return err
-			}
-		}
-
-	case reflect.String:
-		_, err := table.addColumn(name, false, tagData.getWidthParam())
-		if err != nil {
-			return err
-		}
-
-	default:
-		_, err := table.addColumn(name, true, tagData.getWidthParam())
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	_, err := table.addFieldColumn(f.name, f.rightAlign, f.numeric, f.tagInfo)
+	return err
 }
 
-func reflectStructRow(table *tabular, row rowID, value reflect.Value) error {
+// reflectStructRow sets the fields of a row from a struct, starting at column col.
+func reflectStructRow(table *tabular, row rowID, col colID, value reflect.Value) error {
 	// Check we have a struct
 	if value.Kind() != reflect.Struct {
 		panic("unexpected type")
 	}
 
-	n := value.NumField()
-	var err error
-	col := colID(0)
-	for i := 0; i < n; i++ {
-		// t is the structure declared type not the type of the value itself
-		t := value.Type().Field(i)
-		col, err = reflectFieldValue(table, row, col, value.Field(i), t)
-
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return table.visit(value, func(f, nilField *layoutField, v reflect.Value) error {
+		var err error
+		col, err = reflectFieldValue(table, row, col, f, nilField, v)
+		return err
+	})
 }
 
-func reflectFieldValue(table *tabular, row rowID, col colID, value reflect.Value, t reflect.StructField) (colID, error) {
-	tagInfo := getTags(t.Tag, tabularTagName)
-	name := getFieldName(t.Name, tagInfo)
-	if !table.shouldOutputColumn(name) {
+func reflectFieldValue(table *tabular, row rowID, col colID, f, nilField *layoutField, value reflect.Value) (colID, error) {
+	switch {
+	case f.layout == layoutFlatten:
+		// Flattened struct fields are output by the fields that follow
 		return col, nil
-	}
 
-	switch t.Type.Kind() {
-	case reflect.Array, reflect.Slice, reflect.Func, reflect.Map, reflect.Interface, reflect.Ptr:
-		return col, nil
-
-	case reflect.Struct:
-
-		// Nested struct, follow
-		n := value.NumField()
-		var err error
-
-		for i := 0; i < n; i++ {
-			t := value.Type().Field(i)
-			col, err = reflectFieldValue(table, row, col, value.Field(i), t)
-			if err != nil {
-				return col, err
-			}
+	case f.nested:
+		if nilField != nil {
+			return col, nil
 		}
+		return col, table.addNested(row, f.name, value)
+
+	case nilField != nil:
+		// Fields of a nil struct pointer are output as nil
+		return col + 1, table.setField(row, col, "%s", table.nilText(nilField.tagInfo))
 
-	case reflect.Bool:
+	case f.isBool && f.tagInfo != nil && f.tagInfo.Options["trueonly"] && !value.Bool():
 		// Allow alternative handling of bool false
-		if tagInfo != nil && tagInfo.Options["trueonly"] {
-			if !value.Interface().(bool) {
-				return col + 1, table.setField(row, col, "")
-			}
-		}
-		fallthrough
+		return col + 1, table.setField(row, col, "")
+
 	default:
-		return col + 1, table.setField(row, col, "%v", value.Interface())
+		return col + 1, table.setCell(row, col, value, f.tagInfo)
 	}
-
-	return col, nil
 }
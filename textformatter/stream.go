@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// stream writes rows as they arrive.
+// Plain, Markdown and Vertical rows are written immediately, aligned styles buffer
+// StreamSampleRows rows to size the columns before writing.
+type stream struct {
+	writer        io.Writer
+	options       Options
+	table         *tabular
+	aligned       *alignedWriter
+	vertical      *verticalWriter
+	headerWritten bool
+	closed        bool
+	// sectioned is set when a Markdown row was followed by its nested tables, the next row starts a new table.
+	sectioned bool
+	// rows is the number of rows written.
+	rows int
+	// seen is the number of rows received, including those outside the page.
+	seen int
+}
+
+func (f *formatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	textOptions, err := getOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	textOptions = detectTerminal(writer, textOptions)
+
+	if _, _, ok := gridForStyle(textOptions.Style); !ok &&
+		textOptions.Style != Plain && textOptions.Style != Markdown && textOptions.Style != Vertical {
+		return nil, lpax.Errorf(langpack.ErrorUnknownStyle, textOptions.Style)
+	}
+
+	// Rows are written as they arrive so cannot be grouped
+	textOptions.GroupBy = nil
+
+	if textOptions.StreamSampleRows < 1 {
+		textOptions.StreamSampleRows = 1
+	}
+
+	return &stream{
+		writer:  writer,
+		options: textOptions,
+		table:   newStyledTabular(textOptions),
+	}, nil
+}
+
+func (s *stream) Write(row interface{}) error {
+	if s.closed {
+		return lpax.Errorf(langpack.ErrorStreamClosed)
+	}
+
+	table := s.table
+
+	// Rows outside the page are counted for the trailer but not output
+	s.seen++
+	if !table.page.Includes(s.seen - 1) {
+		return nil
+	}
+
+	// The header is taken from the first row, a row that fails is removed so it is not written
+	rows := len(table.rows)
+	if err := reflectArrayItem(table, reflect.ValueOf(row), len(table.columns) == 0); err != nil {
+		table.rows = table.rows[:rows]
+		table.nested = table.nested[:rows]
+		return err
+	}
+
+	switch {
+	case s.options.Style == Plain || s.options.Style == Markdown || s.options.Style == Vertical || s.aligned != nil:
+		return s.flush()
+
+	case len(table.rows) >= s.options.StreamSampleRows:
+		return s.startAligned()
+
+	default:
+		return nil
+	}
+}
+
+// startAligned sizes the columns from the buffered sample rows and writes them out.
+func (s *stream) startAligned() error {
+	s.aligned = s.table.newAlignedWriter(s.writer, s.options.Style, s.options.TerminalWidth)
+
+	if err := s.aligned.writeHeader(s.options.ExcludeHeader); err != nil {
+		return err
+	}
+
+	s.headerWritten = true
+
+	return s.flush()
+}
+
+// flush writes the buffered rows and releases them.
+func (s *stream) flush() error {
+	table := s.table
+
+	if !s.headerWritten && len(table.rows) > 0 {
+		s.headerWritten = true
+
+		switch {
+		case s.options.Style == Vertical:
+			// Records are sized from the first row
+			s.vertical = table.newVerticalWriter(s.writer, s.options.TerminalWidth)
+		case s.options.Style == Markdown:
+			if err := table.writeMarkdownHeader(s.writer); err != nil {
+				return err
+			}
+		case !s.options.ExcludeHeader:
+			if err := table.writePlainHeader(s.writer, s.options.ColumnSeparator); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, row := range table.rows {
+		var err error
+
+		switch {
+		case s.aligned != nil:
+			err = s.aligned.writeRow(row, table.nested[i])
+		case s.vertical != nil:
+			err = s.vertical.writeRecord(s.rows+i+1, row, table.nested[i])
+		case s.options.Style == Markdown:
+			err = s.writeMarkdownRow(row, table.nested[i], s.rows+i+1)
+		default:
+			err = table.writePlainRow(s.writer, row, s.options.ColumnSeparator)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	s.rows += len(table.rows)
+
+	table.rows = table.rows[:0]
+	table.nested = table.nested[:0]
+
+	return nil
+}
+
+// writeMarkdownRow writes a Markdown row followed by its nested tables, which are written as sections as
+// Markdown tables cannot hold them.  A row following sections starts a new table.
+func (s *stream) writeMarkdownRow(row []string, nested []nestedTable, rowNum int) error {
+	if err := s.restartMarkdown(); err != nil {
+		return err
+	}
+
+	if err := s.table.writeMarkdownRow(s.writer, row); err != nil {
+		return err
+	}
+
+	if len(nested) == 0 {
+		return nil
+	}
+
+	s.sectioned = true
+
+	return writeSectionList(s.writer, titledSections(nested, rowNum), Markdown, s.options.TerminalWidth)
+}
+
+// restartMarkdown starts a new Markdown table, separated by a blank line, if sections follow the last row.
+func (s *stream) restartMarkdown() error {
+	if !s.sectioned {
+		return nil
+	}
+	s.sectioned = false
+
+	if _, err := io.WriteString(s.writer, "\n"); err != nil {
+		return err
+	}
+
+	return s.table.writeMarkdownHeader(s.writer)
+}
+
+func (s *stream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if err := s.closeTable(); err != nil {
+		return err
+	}
+
+	if s.table.page.IsSet() {
+		start, end := s.table.page.Window(s.seen)
+		s.table.setTrailer(start, end, s.seen)
+	}
+
+	return s.table.writeTrailer(s.writer, s.options.Style)
+}
+
+// closeTable writes any buffered rows and the footer.
+func (s *stream) closeTable() error {
+	// Totals are sized with the columns when they have not yet been written
+	footer := s.table.footer()
+
+	if s.aligned == nil {
+		if s.options.Style == Markdown {
+			if s.rows > 0 && footer != nil {
+				if err := s.restartMarkdown(); err != nil {
+					return err
+				}
+
+				if err := s.table.writeMarkdownRow(s.writer, footer); err != nil {
+					return err
+				}
+			}
+
+			return s.table.writeSections(s.writer, s.options.Style, s.options.TerminalWidth)
+		}
+
+		if len(s.table.rows) == 0 || s.options.Style == Plain || s.options.Style == Vertical {
+			return nil
+		}
+
+		// Fewer rows than the sample size have been written
+		if err := s.startAligned(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.aligned.writeTotals(footer, 0); err != nil {
+		return err
+	}
+
+	return s.aligned.close()
+}
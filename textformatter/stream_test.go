@@ -0,0 +1,210 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+)
+
+func streamRows(t *testing.T, options Options, rows ...interface{}) string {
+	t.Helper()
+
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, options)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return ""
+	}
+
+	for _, row := range rows {
+		if err := stream.Write(row); err != nil {
+			t.Errorf("Write Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestStreamPlain(t *testing.T) {
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+
+	got := streamRows(t, options, testData2{S: "Hello", B1: true}, &testData2{S: "Bye", B2: true})
+
+	testsupport.CompareStrings(t, "S,B1,B2\nHello,true,\nBye,false,true\n", got)
+}
+
+func TestStreamMarkdown(t *testing.T) {
+	options := NewOptions()
+	options.Style = Markdown
+
+	got := streamRows(t, options, testData2{S: "Hello", B1: true}, testData2{S: "Bye", B2: true})
+
+	expected := `|S|B1|B2|
+|-|-|-|
+|Hello|true||
+|Bye|false|true|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestStreamGridMatchesFormat(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+
+	got := streamRows(t, options, testData2{S: "Hello", B1: true, B2: true}, testData2{S: "Hello"})
+
+	expected := `+-------+-------+------+
+| S     |    B1 |   B2 |
++-------+-------+------+
+| Hello |  true | true |
++-------+-------+------+
+| Hello | false |      |
++-------+-------+------+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestStreamGridSampleWindow(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+	options.StreamSampleRows = 1
+
+	got := streamRows(t, options,
+		testData2{S: "Hello"},
+		testData2{S: "Much longer text"},
+	)
+
+	expected := `+-------+-------+----+
+| S     |    B1 | B2 |
++-------+-------+----+
+| Hello | false |    |
++-------+-------+----+
+| Much  | false |    |
+| longe |       |    |
+| r     |       |    |
+| text  |       |    |
++-------+-------+----+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestStreamEmpty(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+
+	if got := streamRows(t, options); got != "" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestStreamScalars(t *testing.T) {
+	options := NewOptions()
+
+	got := streamRows(t, options, "one", "three")
+
+	testsupport.CompareStrings(t, "Output\none   \nthree \n", got)
+}
+
+func TestStreamClosed(t *testing.T) {
+	f, _ := NewFormatter()
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, nil)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+	}
+
+	_ = stream.Close()
+
+	if err := stream.Write(testData2{}); err == nil {
+		t.Error("No closed error")
+	}
+}
+
+func TestStreamBadStyle(t *testing.T) {
+	f, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Style = TableStyle(890)
+
+	if _, err := f.(yaff.StreamFormatter).NewStream(&bytes.Buffer{}, options); err == nil {
+		t.Error("No style error")
+	}
+}
+
+func TestStreamMaps(t *testing.T) {
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+
+	got := streamRows(t, options, map[string]interface{}{"b": 1, "a": "x"}, map[string]interface{}{"b": 2, "c": true})
+
+	testsupport.CompareStrings(t, "a,b\nx,1\n,2\n", got)
+}
+
+func TestStreamFailedRowDropped(t *testing.T) {
+	type one struct{ A int }
+	type two struct{ A, B int }
+
+	f, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, options)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+	}
+
+	if err := stream.Write(one{A: 1}); err != nil {
+		t.Errorf("Write Error %v", err)
+	}
+
+	if err := stream.Write(two{A: 2, B: 3}); err == nil {
+		t.Error("No column error")
+	}
+
+	if err := stream.Write(one{A: 4}); err != nil {
+		t.Errorf("Write Error %v", err)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "A\n1\n4\n", buf.String())
+}
@@ -36,6 +36,21 @@ const (
 
 	// Markdown uses a markdown table format.
 	Markdown
+
+	// LightBox grid drawn with light unicode box drawing characters.
+	LightBox
+
+	// HeavyBox grid drawn with heavy unicode box drawing characters.
+	HeavyBox
+
+	// DoubleLine grid drawn with double line unicode box drawing characters.
+	DoubleLine
+
+	// Rounded grid drawn with light unicode box drawing characters and rounded corners.
+	Rounded
+
+	// Vertical writes each row as a record of name and value lines, like psql's expanded output.
+	Vertical
 )
 
 // GetTextStyleFromString get the text style for a string.
@@ -49,7 +64,97 @@ func GetTextStyleFromString(style string) (TableStyle, error) {
 		return Grid, nil
 	case "md":
 		return Markdown, nil
+	case "light":
+		return LightBox, nil
+	case "heavy":
+		return HeavyBox, nil
+	case "double":
+		return DoubleLine, nil
+	case "rounded":
+		return Rounded, nil
+	case "vertical":
+		return Vertical, nil
 	default:
 		return Plain, lpax.Errorf(langpack.ErrorUnknownStyle, style)
 	}
 }
+
+// gridLine holds the characters used to draw a horizontal grid line.
+type gridLine struct {
+	left       string
+	horizontal string
+	cross      string
+	right      string
+}
+
+// gridStyle holds the characters used to draw a grid around a table.
+type gridStyle struct {
+	top       gridLine
+	header    gridLine
+	separator gridLine
+	bottom    gridLine
+	vertical  string
+}
+
+var (
+	asciiGrid = &gridStyle{
+		top:       gridLine{"+", "-", "+", "+"},
+		header:    gridLine{"+", "-", "+", "+"},
+		separator: gridLine{"+", "-", "+", "+"},
+		bottom:    gridLine{"+", "-", "+", "+"},
+		vertical:  "|",
+	}
+
+	lightGrid = &gridStyle{
+		top:       gridLine{"┌", "─", "┬", "┐"},
+		header:    gridLine{"├", "─", "┼", "┤"},
+		separator: gridLine{"├", "─", "┼", "┤"},
+		bottom:    gridLine{"└", "─", "┴", "┘"},
+		vertical:  "│",
+	}
+
+	heavyGrid = &gridStyle{
+		top:       gridLine{"┏", "━", "┳", "┓"},
+		header:    gridLine{"┣", "━", "╋", "┫"},
+		separator: gridLine{"┠", "─", "╂", "┨"},
+		bottom:    gridLine{"┗", "━", "┻", "┛"},
+		vertical:  "┃",
+	}
+
+	doubleGrid = &gridStyle{
+		top:       gridLine{"╔", "═", "╦", "╗"},
+		header:    gridLine{"╠", "═", "╬", "╣"},
+		separator: gridLine{"╟", "─", "╫", "╢"},
+		bottom:    gridLine{"╚", "═", "╩", "╝"},
+		vertical:  "║",
+	}
+
+	roundedGrid = &gridStyle{
+		top:       gridLine{"╭", "─", "┬", "╮"},
+		header:    gridLine{"├", "─", "┼", "┤"},
+		separator: gridLine{"├", "─", "┼", "┤"},
+		bottom:    gridLine{"╰", "─", "┴", "╯"},
+		vertical:  "│",
+	}
+)
+
+// gridForStyle returns the grid drawn around an aligned style along with the cell padding.
+// ok is false if the style is not an aligned style.
+func gridForStyle(style TableStyle) (grid *gridStyle, pad int, ok bool) {
+	switch style {
+	case Aligned:
+		return nil, 0, true
+	case Grid:
+		return asciiGrid, 1, true
+	case LightBox:
+		return lightGrid, 1, true
+	case HeavyBox:
+		return heavyGrid, 1, true
+	case DoubleLine:
+		return doubleGrid, 1, true
+	case Rounded:
+		return roundedGrid, 1, true
+	default:
+		return nil, 0, false
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package textformatter
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/nehemming/testsupport"
 )
 
 func TestGetTextStyleFromString(t *testing.T) {
@@ -65,4 +68,90 @@ func TestGetTextStyleFromString(t *testing.T) {
 	if err == nil {
 		t.Error("No error")
 	}
+
+	for name, style := range map[string]TableStyle{
+		"md": Markdown, "light": LightBox, "heavy": HeavyBox, "double": DoubleLine, "rounded": Rounded, "vertical": Vertical,
+	} {
+		ts, err = GetTextStyleFromString(name)
+
+		if err != nil {
+			t.Errorf("Error %v (%v)", err, name)
+		}
+
+		if ts != style {
+			t.Errorf("Value %v (%v)", ts, name)
+		}
+	}
+}
+
+func formatBoxStyle(t *testing.T, style TableStyle) string {
+	t.Helper()
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = style
+
+	err = fmt.Format(&buf, options, []testData2{
+		{S: "Hello", B1: true, B2: true},
+		{S: "Bye", B1: false, B2: false},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestLightBoxStyle(t *testing.T) {
+	expected := `┌───────┬───────┬──────┐
+│ S     │    B1 │   B2 │
+├───────┼───────┼──────┤
+│ Hello │  true │ true │
+├───────┼───────┼──────┤
+│ Bye   │ false │      │
+└───────┴───────┴──────┘
+`
+	testsupport.CompareStrings(t, expected, formatBoxStyle(t, LightBox))
+}
+
+func TestHeavyBoxStyle(t *testing.T) {
+	expected := `┏━━━━━━━┳━━━━━━━┳━━━━━━┓
+┃ S     ┃    B1 ┃   B2 ┃
+┣━━━━━━━╋━━━━━━━╋━━━━━━┫
+┃ Hello ┃  true ┃ true ┃
+┠───────╂───────╂──────┨
+┃ Bye   ┃ false ┃      ┃
+┗━━━━━━━┻━━━━━━━┻━━━━━━┛
+`
+	testsupport.CompareStrings(t, expected, formatBoxStyle(t, HeavyBox))
+}
+
+func TestDoubleLineStyle(t *testing.T) {
+	expected := `╔═══════╦═══════╦══════╗
+║ S     ║    B1 ║   B2 ║
+╠═══════╬═══════╬══════╣
+║ Hello ║  true ║ true ║
+╟───────╫───────╫──────╢
+║ Bye   ║ false ║      ║
+╚═══════╩═══════╩══════╝
+`
+	testsupport.CompareStrings(t, expected, formatBoxStyle(t, DoubleLine))
+}
+
+func TestRoundedStyle(t *testing.T) {
+	expected := `╭───────┬───────┬──────╮
+│ S     │    B1 │   B2 │
+├───────┼───────┼──────┤
+│ Hello │  true │ true │
+├───────┼───────┼──────┤
+│ Bye   │ false │      │
+╰───────┴───────┴──────╯
+`
+	testsupport.CompareStrings(t, expected, formatBoxStyle(t, Rounded))
 }
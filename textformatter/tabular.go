@@ -19,13 +19,13 @@ package textformatter
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/nehemming/lpax"
 	"github.com/nehemming/yaff/langpack"
-
-	"github.com/eidolon/wordwrap"
+	"github.com/nehemming/yaff/rowset"
 )
 
 // rowID row ID.
@@ -39,6 +39,10 @@ type column struct {
 	width      int
 	rightAlign bool
 	minWidth   int
+	color      Color
+	// total accumulates the values of an aggregated column.
+	total   *rowset.Accumulator
+	tagInfo *tagData
 }
 
 // Tabular data.
@@ -47,6 +51,36 @@ type tabular struct {
 	rows       [][]string
 	columnSet  map[string]bool
 	excludeSet map[string]bool
+	colors     *colorScheme
+	sortBy     []rowset.SortKey
+	// columnSpecs when set select the columns output and their order.
+	columnSpecs []rowset.ColumnSpec
+	specPlans   map[reflect.Type][]specColumn
+	// mapKeys are the map keys of each column when the rows are maps.
+	mapKeys []string
+	// nested holds the nested tables of each row, sections are written after the table.
+	nested   [][]nestedTable
+	sections []nestedTable
+	// options are used to create nested tables.
+	options Options
+	// selections holds the fields selected from each struct layout.
+	selections map[*structLayout][]bool
+	aggregates map[string]rowset.Aggregate
+	// groupBy are the names of the columns rows are grouped by, when isArray is set.
+	groupBy   []string
+	subtotals bool
+	isArray   bool
+	// cells holds the values of the aggregated columns of each row for subtotals.
+	cells          [][]reflect.Value
+	nilPlaceholder string
+	listSeparator  string
+	// page selects the array rows output, trailer describes them beneath the table.
+	page    rowset.Page
+	trailer string
+	// pageSize is the number of rows between repeated headers.
+	pageSize int
+	// sized is set once aligned output has fixed the column widths.
+	sized bool
 }
 
 // newTabular create a new tabular output.
@@ -60,10 +94,11 @@ func newTabular(columnSet map[string]bool, excludeSet map[string]bool) *tabular
 	}
 
 	return &tabular{
-		columns:    make([]*column, 0, 2),
-		rows:       make([][]string, 0, 2),
-		columnSet:  columnSet,
-		excludeSet: excludeSet,
+		columns:       make([]*column, 0, 2),
+		rows:          make([][]string, 0, 2),
+		columnSet:     columnSet,
+		excludeSet:    excludeSet,
+		listSeparator: defaultListSeparator,
 	}
 }
 
@@ -86,15 +121,46 @@ func (tablet *tabular) shouldOutputColumn(columnName string) bool {
 	return true
 }
 
+// rowOrder returns the order to visit the items of an array in, sorted if sort keys are set.
+func (tablet *tabular) rowOrder(value reflect.Value) ([]int, error) {
+	if len(tablet.sortBy) > 0 {
+		return rowset.SortIndices(value, tablet.sortBy)
+	}
+
+	order := make([]int, value.Len())
+	for i := range order {
+		order[i] = i
+	}
+
+	return order, nil
+}
+
 // AddColumn add a column to the output.
-func (tablet *tabular) addColumn(name string, rightAlign bool, wrapFmt string) (colID, error) {
+// tagInfo holds any tabular tag params for the column and can be nil.
+func (tablet *tabular) addColumn(name string, rightAlign bool, tagInfo *tagData) (colID, error) {
+	// Right aligned columns are taken to hold numbers, numeric aggregates ignore any that are not
+	return tablet.addFieldColumn(name, rightAlign, rightAlign, tagInfo)
+}
+
+// addFieldColumn adds a column for a struct field, numeric is set if the field kind is a number.
+func (tablet *tabular) addFieldColumn(name string, rightAlign, numeric bool, tagInfo *tagData) (colID, error) {
 	// Add a column to end
 	c := len(tablet.columns)
 
-	nameLen := len(name)
+	nameLen := displayWidth(name)
 	minWidth := nameLen
 
-	if wrapFmt != "" {
+	color, err := tablet.colors.columnColor(name, tagInfo)
+	if err != nil {
+		return colID(c), err
+	}
+
+	total, err := tablet.columnTotal(name, numeric, tagInfo)
+	if err != nil {
+		return colID(c), err
+	}
+
+	if wrapFmt := tagInfo.getWidthParam(); wrapFmt != "" {
 		w, err := strconv.ParseInt(wrapFmt, 10, 32)
 		if err != nil {
 			return colID(c), err
@@ -109,6 +175,9 @@ func (tablet *tabular) addColumn(name string, rightAlign bool, wrapFmt string) (
 		rightAlign: rightAlign,
 		width:      nameLen,
 		minWidth:   minWidth, // minWidth is the smallest width of the column
+		color:      color,
+		total:      total,
+		tagInfo:    tagInfo,
 	})
 
 	return colID(c), nil
@@ -121,6 +190,7 @@ func (tablet *tabular) newRow() rowID {
 	r := len(tablet.rows)
 
 	tablet.rows = append(tablet.rows, make([]string, c))
+	tablet.nested = append(tablet.nested, nil)
 
 	return rowID(r)
 }
@@ -136,12 +206,15 @@ func (tablet *tabular) setField(row rowID, column colID, format string, value ..
 
 	s := fmt.Sprintf(format, value...)
 
-	tablet.rows[row][column] = s
+	// Remove any styling already present in the value when color is turned off
+	if tablet.colors != nil && tablet.colors.disabled {
+		s = stripEscapes(s)
+	}
 
-	w := tablet.columns[column].width
+	tablet.rows[row][column] = s
 
-	if w < len(s) {
-		tablet.columns[column].width = len(s)
+	if w := displayWidth(s); !tablet.sized && tablet.columns[column].width < w {
+		tablet.columns[column].width = w
 	}
 
 	return nil
@@ -149,28 +222,43 @@ func (tablet *tabular) setField(row rowID, column colID, format string, value ..
 
 // write output the table to an io writer using the supplied table style.
 func (tablet *tabular) write(out io.Writer, style TableStyle, excludeHeader bool, columnSeparator string, terminalWidth int) error {
+	if err := tablet.writeTable(out, style, excludeHeader, columnSeparator, terminalWidth); err != nil {
+		return err
+	}
+
+	return tablet.writeTrailer(out, style)
+}
+
+// writeTable writes the table and any sections following it.
+func (tablet *tabular) writeTable(out io.Writer, style TableStyle, excludeHeader bool, columnSeparator string, terminalWidth int) error {
 	switch style {
 	case Plain:
 		return tablet.writePlain(out, excludeHeader, columnSeparator)
 
-	case Aligned:
-		return tablet.writeAligned(out, excludeHeader, false, 0, terminalWidth)
+	case Markdown:
+		if err := tablet.writeMarkdown(out); err != nil {
+			return err
+		}
 
-	case Grid:
-		return tablet.writeAligned(out, excludeHeader, true, 1, terminalWidth)
+		return tablet.writeSections(out, style, terminalWidth)
 
-	case Markdown:
-		return tablet.writeMarkdown(out)
+	case Vertical:
+		if err := tablet.writeVertical(out, terminalWidth); err != nil {
+			return err
+		}
+
+		return tablet.writeSections(out, style, terminalWidth)
+	}
 
-	default:
+	if _, _, ok := gridForStyle(style); !ok {
 		return lpax.Errorf(langpack.ErrorUnknownStyle, style)
 	}
-}
 
-type wrappedTable struct {
-	totalSpacing     int
-	spacing          []int
-	rowsLinesColumns [][][]string
+	if err := tablet.writeAligned(out, style, excludeHeader, terminalWidth); err != nil {
+		return err
+	}
+
+	return tablet.writeSections(out, style, terminalWidth)
 }
 
 func (tablet *tabular) calcWidths(minSpacing, terminalWidth int) {
@@ -226,120 +314,239 @@ func (tablet *tabular) calculateSpacing(hasGrid bool, pad int) (spacing []int, t
 	return
 }
 
-func wrapText(text string, width int) []string {
-	wrapped := wordwrap.Wrapper(width, true)(text)
-	return strings.Split(wrapped, "\n")
+// cellColors returns the colors used to style each field in a row.
+func (tablet *tabular) cellColors(row []string) []Color {
+	colors := make([]Color, len(row))
+	for i, field := range row {
+		colors[i] = tablet.colors.cellColor(tablet.columns[i], field)
+	}
+	return colors
+}
+
+// wrapRow splits the fields of a row into lines that fit the column widths.
+// When wrapAll is set every field is wrapped to the columns minimum width,
+// otherwise only fields wider than their column are wrapped.
+func (tablet *tabular) wrapRow(r []string, wrapAll bool) [][]string {
+	c := len(tablet.columns)
+	linesPerRow := 1
+	columnLines := make([][]string, c)
+
+	for fID, field := range r {
+		col := tablet.columns[fID]
+
+		var lines []string
+		switch {
+		case wrapAll:
+			lines = wrapText(field, col.minWidth)
+		case displayWidth(field) > col.width:
+			lines = wrapText(field, col.width)
+		default:
+			lines = []string{field}
+		}
+
+		if len(lines) > linesPerRow {
+			linesPerRow = len(lines)
+		}
+		columnLines[fID] = lines
+	}
+
+	if linesPerRow == 1 && !wrapAll {
+		return [][]string{r}
+	}
+
+	// Pivot from column line to line column order
+	lineColumns := make([][]string, linesPerRow)
+	for j := 0; j < linesPerRow; j++ {
+		cols := make([]string, c)
+		for i, linesPerCol := range columnLines {
+			if j < len(linesPerCol) {
+				cols[i] = linesPerCol[j]
+			}
+		}
+		lineColumns[j] = cols
+	}
+
+	return lineColumns
+}
+
+// alignedWriter writes rows aligned to fixed column widths.
+type alignedWriter struct {
+	tablet       *tabular
+	out          io.Writer
+	style        TableStyle
+	grid         *gridStyle
+	pad          int
+	spacing      []int
+	totalSpacing int
+	wrapAll      bool
+	rows         int
+	// header is set when the header is written, it is repeated every pageSize rows.
+	header   bool
+	pageSize int
+	// merged is the number of leading columns merged with the row above.
+	merged int
+	// terminalWidth is used to size nested tables.
+	terminalWidth int
 }
 
-func (tablet *tabular) buildWrappedTable(hasGrid bool, pad int, terminalWidth int) *wrappedTable {
-	rows := make([][][]string, len(tablet.rows))
+// newAlignedWriter sizes the columns using the rows currently held by the table.
+// Column widths are fixed from this point on, later rows wider than a column are wrapped.
+func (tablet *tabular) newAlignedWriter(out io.Writer, style TableStyle, terminalWidth int) *alignedWriter {
+	grid, pad, _ := gridForStyle(style)
+	hasGrid := grid != nil
 
 	spacing, totalSpacing, minSpacing := tablet.calculateSpacing(hasGrid, pad)
 
-	if terminalWidth > 0 && totalSpacing > terminalWidth {
+	wrapAll := terminalWidth > 0 && totalSpacing > terminalWidth
+	if wrapAll {
 		tablet.calcWidths(minSpacing, terminalWidth)
 		// recalculate spacings
 		spacing, totalSpacing, _ = tablet.calculateSpacing(hasGrid, pad)
+	}
 
-		// Build output in in row column line order and then pivot
-		c := len(tablet.columns)
-		for rowID, r := range tablet.rows {
-			linesPerRow := 1
-			columnLines := make([][]string, c)
-			for fID, field := range r {
-				width := tablet.columns[fID].minWidth
-				lines := wrapText(field, width)
-				if len(lines) > linesPerRow {
-					linesPerRow = len(lines)
-				}
-				columnLines[fID] = lines
-			}
+	tablet.sized = true
 
-			// Pivot
-			lineColumns := make([][]string, linesPerRow)
-			for j := 0; j < linesPerRow; j++ {
-				cols := make([]string, c)
-				for i, linesPerCol := range columnLines {
-					if j >= len(linesPerCol) {
-						cols[i] = ""
-					} else {
-						cols[i] = linesPerCol[j]
-					}
-				}
-				lineColumns[j] = cols
-			}
-			rows[rowID] = lineColumns
-		}
-	} else {
-		// sinple
-		for rowID, r := range tablet.rows {
-			l := []([]string){r}
-			rows[rowID] = l
+	return &alignedWriter{
+		tablet:       tablet,
+		out:          out,
+		style:        style,
+		grid:         grid,
+		pad:          pad,
+		spacing:      spacing,
+		totalSpacing: totalSpacing,
+		wrapAll:      wrapAll,
+		pageSize:     tablet.pageSize,
+
+		terminalWidth: terminalWidth,
+	}
+}
+
+// writeHeader writes the opening grid line and header.
+func (aw *alignedWriter) writeHeader(excludeHeader bool) error {
+	// Write opening grid line
+	if aw.grid != nil {
+		if err := writeGridLine(aw.out, aw.totalSpacing, aw.spacing, aw.grid.top); err != nil {
+			return err
 		}
 	}
 
-	wrapTable := &wrappedTable{
-		totalSpacing:     totalSpacing,
-		spacing:          spacing,
-		rowsLinesColumns: rows,
+	if excludeHeader {
+		return nil
 	}
 
-	return wrapTable
-}
+	aw.header = true
 
-func (tablet *tabular) writeAligned(out io.Writer, excludeHeader bool, hasGrid bool, pad int, terminalWidth int) error {
-	// Column width aligned output
-	if len(tablet.rows) == 0 {
-		return nil
+	if err := aw.tablet.writeAlignedHeader(aw.out, aw.grid, aw.pad, aw.totalSpacing); err != nil {
+		return err
 	}
 
-	wrappedTable := tablet.buildWrappedTable(hasGrid, pad, terminalWidth)
+	// add in header grid line
+	if aw.grid != nil {
+		return writeGridLine(aw.out, aw.totalSpacing, aw.spacing, aw.grid.header)
+	}
 
-	// Write opening grid line
-	if hasGrid {
-		if err := writeGridLine(out, wrappedTable.totalSpacing,
-			wrappedTable.spacing); err != nil {
+	return nil
+}
+
+// writeRow writes a row, separating it from any previous row, followed by its nested tables.
+func (aw *alignedWriter) writeRow(row []string, nested []nestedTable) error {
+	switch {
+	case aw.rows > 0 && aw.header && aw.pageSize > 0 && aw.rows%aw.pageSize == 0:
+		if err := aw.repeatHeader(); err != nil {
 			return err
 		}
-	}
 
-	// Write header
-	if !excludeHeader {
-		if err := tablet.writeAlignedHeader(out, hasGrid, pad,
-			wrappedTable.totalSpacing); err != nil {
+	case aw.grid != nil && aw.rows > 0:
+		if err := writeMergedGridLine(aw.out, aw.totalSpacing, aw.spacing, aw.merged, aw.grid.separator,
+			aw.grid.vertical); err != nil {
 			return err
 		}
+	}
 
-		// add in header grid line
-		if hasGrid {
-			if err := writeGridLine(out, wrappedTable.totalSpacing,
-				wrappedTable.spacing); err != nil {
-				return err
-			}
-		}
+	aw.rows++
+
+	if err := aw.tablet.writeAlignedRow(aw.out, aw.tablet.wrapRow(row, aw.wrapAll), aw.tablet.cellColors(row),
+		aw.grid, aw.pad, aw.totalSpacing); err != nil {
+		return err
+	}
+
+	return writeNested(aw.out, nested, aw.style, aw.terminalWidth)
+}
+
+// writeTotals writes a totals row, beneath a header style grid line when the style has a grid.
+// The grid line is not drawn beneath the first merged columns.
+func (aw *alignedWriter) writeTotals(totals []string, merged int) error {
+	if totals == nil {
+		return nil
 	}
 
-	// Walk through rows
-	for _, row := range wrappedTable.rowsLinesColumns {
-		// output row
-		if err := tablet.writeAlignedRow(out, row, hasGrid, pad,
-			wrappedTable.totalSpacing); err != nil {
+	if aw.grid != nil {
+		if err := writeMergedGridLine(aw.out, aw.totalSpacing, aw.spacing, merged, aw.grid.header,
+			aw.grid.vertical); err != nil {
 			return err
 		}
+	}
+
+	return aw.tablet.writeAlignedRow(aw.out, aw.tablet.wrapRow(totals, aw.wrapAll), aw.tablet.cellColors(totals),
+		aw.grid, aw.pad, aw.totalSpacing)
+}
+
+// close writes the closing grid line.
+func (aw *alignedWriter) close() error {
+	if aw.grid == nil {
+		return nil
+	}
+
+	return writeGridLine(aw.out, aw.totalSpacing, aw.spacing, aw.grid.bottom)
+}
+
+func (tablet *tabular) writeAligned(out io.Writer, style TableStyle, excludeHeader bool, terminalWidth int) error {
+	// Column width aligned output
+	if len(tablet.rows) == 0 {
+		return nil
+	}
+
+	grid, _, _ := gridForStyle(style)
 
-		// add in grid line
-		if hasGrid {
-			if err := writeGridLine(out, wrappedTable.totalSpacing, wrappedTable.spacing); err != nil {
-				return err
+	groups, err := tablet.groupRows(grid != nil)
+	if err != nil {
+		return err
+	}
+
+	footer := tablet.footer()
+	aw := tablet.newAlignedWriter(out, style, terminalWidth)
+
+	if err := aw.writeHeader(excludeHeader); err != nil {
+		return err
+	}
+
+	if groups != nil {
+		err = aw.writeGroups(groups)
+	} else {
+		// Walk through rows
+		for i, row := range tablet.rows {
+			if err = aw.writeRow(row, tablet.nested[i]); err != nil {
+				break
 			}
 		}
 	}
 
-	return nil
+	if err != nil {
+		return err
+	}
+
+	if err := aw.writeTotals(footer, 0); err != nil {
+		return err
+	}
+
+	return aw.close()
 }
 
 func (tablet *tabular) writePlain(out io.Writer, excludeHeader bool, columnSeparator string) error {
-	// basic raw output, using a separator between columns
+	// basic raw output, using a separator between columns, grouped rows are output in group order
+	if _, err := tablet.groupRows(false); err != nil {
+		return err
+	}
 
 	if !excludeHeader {
 		if err := tablet.writePlainHeader(out, columnSeparator); err != nil {
@@ -358,6 +565,14 @@ func (tablet *tabular) writePlain(out io.Writer, excludeHeader bool, columnSepar
 
 func (tablet *tabular) writeMarkdown(out io.Writer) error {
 	// basic raw output, using a separator between columns
+	groups, err := tablet.groupRows(false)
+	if err != nil {
+		return err
+	}
+
+	if groups != nil {
+		return tablet.writeMarkdownGroups(out, groups)
+	}
 
 	if err := tablet.writeMarkdownHeader(out); err != nil {
 		return err
@@ -369,7 +584,7 @@ func (tablet *tabular) writeMarkdown(out io.Writer) error {
 		}
 	}
 
-	return nil
+	return tablet.writeMarkdownRow(out, tablet.footer())
 }
 
 func (tablet *tabular) writeMarkdownHeader(out io.Writer) error {
@@ -434,9 +649,11 @@ func (tablet *tabular) writePlainHeader(out io.Writer, columnSeparator string) e
 	//nolint:errcheck
 	defer out.Write([]byte("\n"))
 
+	headerColor := tablet.colors.headerColor()
+
 	var sep string
 	for i, col := range tablet.columns {
-		_, err := out.Write([]byte(sep + col.name))
+		_, err := out.Write([]byte(sep + headerColor.apply(col.name)))
 		if err != nil {
 			return err
 		}
@@ -457,9 +674,11 @@ func (tablet *tabular) writePlainRow(out io.Writer, row []string, columnSeparato
 	//nolint:errcheck
 	defer out.Write([]byte("\n"))
 
+	colors := tablet.cellColors(row)
+
 	var sep string
 	for i, field := range row {
-		_, err := out.Write([]byte(sep + field))
+		_, err := out.Write([]byte(sep + colors[i].apply(field)))
 		if err != nil {
 			return err
 		}
@@ -471,7 +690,7 @@ func (tablet *tabular) writePlainRow(out io.Writer, row []string, columnSeparato
 	return nil
 }
 
-func (tablet *tabular) writeAlignedRow(out io.Writer, row [][]string, hasGrid bool, padding, total int) error {
+func (tablet *tabular) writeAlignedRow(out io.Writer, row [][]string, colors []Color, grid *gridStyle, padding, total int) error {
 	if len(tablet.columns) == 0 {
 		return nil
 	}
@@ -483,29 +702,30 @@ func (tablet *tabular) writeAlignedRow(out io.Writer, row [][]string, hasGrid bo
 	for _, line := range row {
 		for i, field := range line {
 			col := tablet.columns[i]
-			w := col.width
-			fill := w - len(field)
+			fill := fillWidth(field, col.width)
 
-			if hasGrid {
-				b.WriteString("|")
+			if grid != nil {
+				b.WriteString(grid.vertical)
 			} else if i > 0 {
 				b.WriteString(" ")
 			}
 
 			// add padding to left or right
+			field = colors[i].apply(field)
+
 			if col.rightAlign {
-				b.WriteString(strings.Repeat(" ", fill+padding))
+				b.WriteString(fill + strings.Repeat(" ", padding))
 				b.WriteString(field)
 				b.WriteString(strings.Repeat(" ", padding))
 			} else {
 				b.WriteString(strings.Repeat(" ", padding))
 				b.WriteString(field)
-				b.WriteString(strings.Repeat(" ", fill+padding))
+				b.WriteString(fill + strings.Repeat(" ", padding))
 			}
 		}
 		// complete grid
-		if hasGrid {
-			b.WriteString("|")
+		if grid != nil {
+			b.WriteString(grid.vertical)
 		}
 
 		// add in close
@@ -517,7 +737,7 @@ func (tablet *tabular) writeAlignedRow(out io.Writer, row [][]string, hasGrid bo
 	return err
 }
 
-func (tablet *tabular) writeAlignedHeader(out io.Writer, hasGrid bool, padding, total int) error {
+func (tablet *tabular) writeAlignedHeader(out io.Writer, grid *gridStyle, padding, total int) error {
 	// write out the aligned header
 	if len(tablet.columns) == 0 {
 		return nil
@@ -526,29 +746,31 @@ func (tablet *tabular) writeAlignedHeader(out io.Writer, hasGrid bool, padding,
 	var b strings.Builder
 	b.Grow(total)
 
+	headerColor := tablet.colors.headerColor()
+
 	for i, col := range tablet.columns {
-		w := col.width
-		fill := w - len(col.name)
+		fill := fillWidth(col.name, col.width)
+		name := headerColor.apply(col.name)
 
-		if hasGrid {
-			b.WriteString("|")
+		if grid != nil {
+			b.WriteString(grid.vertical)
 		} else if i > 0 {
 			b.WriteString(" ")
 		}
 
 		if col.rightAlign {
-			b.WriteString(strings.Repeat(" ", fill+padding))
-			b.WriteString(col.name)
+			b.WriteString(fill + strings.Repeat(" ", padding))
+			b.WriteString(name)
 			b.WriteString(strings.Repeat(" ", padding))
 		} else {
 			b.WriteString(strings.Repeat(" ", padding))
-			b.WriteString(col.name)
-			b.WriteString(strings.Repeat(" ", fill+padding))
+			b.WriteString(name)
+			b.WriteString(fill + strings.Repeat(" ", padding))
 		}
 	}
 
-	if hasGrid {
-		b.WriteString("|")
+	if grid != nil {
+		b.WriteString(grid.vertical)
 	}
 
 	b.WriteString("\n")
@@ -558,17 +780,22 @@ func (tablet *tabular) writeAlignedHeader(out io.Writer, hasGrid bool, padding,
 	return err
 }
 
-func writeGridLine(out io.Writer, total int, spacing []int) error {
-	// output a simple grid line
+func writeGridLine(out io.Writer, total int, spacing []int, line gridLine) error {
+	// output a grid line, the cross character separating columns
 	var b strings.Builder
 	b.Grow(total)
 
-	b.WriteString("+")
+	b.WriteString(line.left)
 
-	for _, c := range spacing {
-		b.WriteString(strings.Repeat("-", c) + "+")
+	for i, c := range spacing {
+		b.WriteString(strings.Repeat(line.horizontal, c))
+		if i < len(spacing)-1 {
+			b.WriteString(line.cross)
+		}
 	}
 
+	b.WriteString(line.right)
+
 	b.WriteString("\n")
 
 	_, err := out.Write([]byte(b.String()))
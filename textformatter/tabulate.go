@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"context"
+	"reflect"
+)
+
+// Tabulator reflects data into rows of text cells using the column plan of the text formatter,
+// so other formatters can output the same columns as text.
+type Tabulator struct {
+	table *tabular
+	// rows is the number of rows reflected.
+	rows int
+}
+
+// NewTabulator returns a tabulator using the column, format, sort, paging and aggregate options.
+// Styling, wrapping, grouping and nested table options are ignored.
+func NewTabulator(options Options) *Tabulator {
+	options = normalizeOptions(options)
+	options.NoColor = true
+	options.NestedDepth = 0
+	options.GroupBy = nil
+
+	// Cells keep any escape sequences in the data as there is no styling to remove
+	table := newStyledTabular(options)
+	table.colors = nil
+
+	return &Tabulator{table: table}
+}
+
+// Tabulate reflects data into rows, a struct is a single row rather than a detail table.
+// The header of an empty slice of structs is taken from its element type.
+func (tab *Tabulator) Tabulate(ctx context.Context, data interface{}) ([][]string, error) {
+	table := tab.table
+	value := asRecords(reflect.ValueOf(data))
+
+	if err := reflectInterface(ctx, table, value); err != nil {
+		return nil, err
+	}
+
+	if len(table.columns) == 0 {
+		if err := reflectEmptyHeader(table, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return tab.take(), nil
+}
+
+// Row reflects a single row, the header is taken from the first row.
+// Nil is returned if the row has no cells.
+func (tab *Tabulator) Row(row interface{}) ([]string, error) {
+	table := tab.table
+
+	if err := reflectArrayItem(table, reflect.ValueOf(row), len(table.columns) == 0); err != nil {
+		return nil, err
+	}
+
+	if rows := tab.take(); len(rows) > 0 {
+		return rows[0], nil
+	}
+
+	return nil, nil
+}
+
+// Header returns the column names, it is empty until the first row is reflected.
+func (tab *Tabulator) Header() []string {
+	header := make([]string, len(tab.table.columns))
+	for i, col := range tab.table.columns {
+		header[i] = col.name
+	}
+	return header
+}
+
+// Footer returns the totals of the aggregated columns of the rows reflected, nil if there are none.
+func (tab *Tabulator) Footer() []string {
+	if tab.rows == 0 {
+		return nil
+	}
+	return tab.table.footer()
+}
+
+// take returns the rows held by the table and releases them.
+func (tab *Tabulator) take() [][]string {
+	table := tab.table
+	rows := table.rows
+	tab.rows += len(rows)
+
+	table.rows = make([][]string, 0, 2)
+	table.nested = table.nested[:0]
+	table.cells = nil
+
+	return rows
+}
+
+// reflectEmptyHeader adds the columns of the element type of an empty slice or array of structs.
+func reflectEmptyHeader(table *tabular, value reflect.Value) error {
+	if value.Kind() == reflect.Ptr {
+		value = reflect.Indirect(value)
+	}
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil
+	}
+
+	t := value.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || isCellValue(t) {
+		return nil
+	}
+
+	if len(table.columnSpecs) > 0 {
+		return reflectSpecHeader(table, t)
+	}
+
+	return reflectStructHeader(table, reflect.New(t).Elem())
+}
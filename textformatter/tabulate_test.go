@@ -0,0 +1,68 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nehemming/yaff/rowset"
+)
+
+func TestTabulatorRows(t *testing.T) {
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"size": rowset.Sum}
+
+	tab := NewTabulator(options)
+
+	rows, err := tab.Tabulate(context.Background(), pageRows()[:2])
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if !reflect.DeepEqual(rows, [][]string{{"a", "1"}, {"b", "2"}}) {
+		t.Errorf("unexpected rows %v", rows)
+	}
+
+	if header := tab.Header(); !reflect.DeepEqual(header, []string{"Name", "Size"}) {
+		t.Errorf("unexpected header %v", header)
+	}
+
+	if footer := tab.Footer(); !reflect.DeepEqual(footer, []string{"Sum", "3"}) {
+		t.Errorf("unexpected footer %v", footer)
+	}
+}
+
+func TestTabulatorRow(t *testing.T) {
+	tab := NewTabulator(NewOptions())
+
+	if footer := tab.Footer(); footer != nil {
+		t.Errorf("unexpected footer %v", footer)
+	}
+
+	for _, row := range pageRows()[:2] {
+		cells, err := tab.Row(row)
+		if err != nil || len(cells) != 2 || cells[0] != row.Name {
+			t.Errorf("unexpected row %v %v", cells, err)
+		}
+	}
+
+	if cells, err := tab.Row(nil); cells != nil || err != nil {
+		t.Errorf("unexpected nil row %v %v", cells, err)
+	}
+}
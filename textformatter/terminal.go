@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// TerminalSize returns the width of the terminal a writer outputs to, ok is false if the writer is not a terminal.
+// A width of 0 is returned for a terminal whose size is unknown.
+type TerminalSize func(writer io.Writer) (width int, ok bool)
+
+// columnsEnv is the environment variable that overrides the width of a terminal.
+const columnsEnv = "COLUMNS"
+
+// DetectTerminal is the default TerminalSize, the writer is a terminal if it is an *os.File attached to one.
+func DetectTerminal(writer io.Writer) (int, bool) {
+	f, ok := writer.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0, false
+	}
+
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0, true
+	}
+
+	return width, true
+}
+
+// detectTerminal updates the options for the writer when AutoDetect is set.
+// Terminals wrap to their width unless TerminalWidth is set, COLUMNS taking precedence over the
+// detected width.  Other writers have aligned, grid and vertical styles written as Plain without color.
+func detectTerminal(writer io.Writer, options Options) Options {
+	if !options.AutoDetect {
+		return options
+	}
+
+	size := options.TerminalSize
+	if size == nil {
+		size = DetectTerminal
+	}
+
+	width, ok := size(writer)
+	if !ok {
+		if _, _, aligned := gridForStyle(options.Style); aligned || options.Style == Vertical {
+			options.Style = Plain
+		}
+		options.NoColor = true
+
+		return options
+	}
+
+	if options.TerminalWidth == 0 {
+		if columns, err := strconv.Atoi(os.Getenv(columnsEnv)); err == nil && columns > 0 {
+			width = columns
+		}
+
+		options.TerminalWidth = width
+	}
+
+	return options
+}
@@ -0,0 +1,84 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+)
+
+func terminalOfWidth(width int) TerminalSize {
+	return func(writer io.Writer) (int, bool) {
+		return width, true
+	}
+}
+
+func notTerminal(writer io.Writer) (int, bool) {
+	return 0, false
+}
+
+func TestDetectTerminalBuffer(t *testing.T) {
+	if _, ok := DetectTerminal(&bytes.Buffer{}); ok {
+		t.Error("buffer detected as a terminal")
+	}
+}
+
+func TestAutoDetectPiped(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+	options.ColumnSeparator = ","
+	options.HeaderColor = Bold
+	options.AutoDetect = true
+	options.TerminalSize = notTerminal
+
+	got := formatStyle(t, options, pageRows()[:2])
+
+	testsupport.CompareStrings(t, "Name,Size\na,1\nb,2\n", got)
+}
+
+func TestAutoDetectTerminalWidth(t *testing.T) {
+	os.Unsetenv(columnsEnv)
+
+	options := NewOptions()
+	options.AutoDetect = true
+	options.TerminalSize = terminalOfWidth(12)
+
+	got := formatStyle(t, options, []struct{ Text string }{{"wraps at the terminal width"}})
+
+	expected := "Text       \nwraps at   \nthe        \nterminal   \nwidth      \n"
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestAutoDetectColumns(t *testing.T) {
+	os.Setenv(columnsEnv, "20")
+	defer os.Unsetenv(columnsEnv)
+
+	options := detectTerminal(nil, Options{AutoDetect: true, TerminalSize: terminalOfWidth(12)})
+	if options.TerminalWidth != 20 {
+		t.Errorf("unexpected width %d", options.TerminalWidth)
+	}
+
+	// An explicit width is kept
+	options = detectTerminal(nil, Options{AutoDetect: true, TerminalWidth: 30, TerminalSize: terminalOfWidth(12)})
+	if options.TerminalWidth != 30 {
+		t.Errorf("unexpected width %d", options.TerminalWidth)
+	}
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package textformatter
 
 import (
+	"context"
 	"io"
 	"reflect"
 	"strings"
@@ -25,6 +26,7 @@ import (
 	"github.com/nehemming/lpax"
 	"github.com/nehemming/yaff"
 	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
 )
 
 // Text format.
@@ -44,23 +46,80 @@ type Options struct {
 	ColumnSeparator string
 	ColumnSet       map[string]bool
 	ExcludeSet      map[string]bool
+	// Columns is an ordered list of the columns to output and their headers.
+	// When set it takes precedence over ColumnSet and ExcludeSet.
+	Columns []rowset.ColumnSpec
 	// TerminalWidth is the width oth the terminal the aligned text is being output to
 	// if this is 0 no wrapping will be used.  For values > column min width this value will
 	// be used to wrap text.
 	TerminalWidth int
+	// AutoDetect checks whether the writer is a terminal using TerminalSize.
+	// Terminal output wraps to the terminal width when TerminalWidth is 0, taken from the COLUMNS
+	// environment variable if set.  Piped output writes aligned, grid and vertical styles as Plain
+	// without color.
+	AutoDetect bool
+	// TerminalSize detects the terminal when AutoDetect is set, it defaults to DetectTerminal.
+	TerminalSize TerminalSize
+	// HeaderColor is the color used to style the header row.
+	HeaderColor Color
+	// ColumnColors maps column names to colors, overriding any color tag on the column.
+	ColumnColors map[string]Color
+	// CellColors maps column names to cell values and the color used when a cell has that value,
+	// i.e. CellColors["status"]["failed"] = Red.
+	CellColors map[string]map[string]Color
+	// NoColor disables styling and removes any escape sequences from the output.
+	// Styling is also disabled when the NO_COLOR environment variable is set.
+	NoColor bool
+	// NilText is the text output for nil pointers, slices and maps, the nil tag param overrides it for a field.
+	NilText string
+	// ListSeparator separates the items of slices and maps, the sep tag param overrides it for a field.
+	// It defaults to ", " when empty.
+	ListSeparator string
+	// NestedDepth is the depth of nested tables output for slice of struct fields, 0 omits them.
+	// Aligned and grid styles write nested tables beneath their row and markdown writes them in
+	// titled sections after the table, as all three do for the fields of a single struct.
+	// Plain output omits nested tables.
+	NestedDepth int
+	// Aggregates maps column names to the aggregate totalled in a footer row beneath the table,
+	// overriding any agg tag param on the column.  Plain output omits the footer.
+	Aggregates map[string]rowset.Aggregate
+	// GroupBy are the names of the columns array rows are grouped by, the same names used by ColumnSet.
+	// Groups are output in the order of their first row, so SortBy can be used to order them.
+	// Grid styles move the group columns first and merge their cells down each group, aligned
+	// and markdown styles write a heading before each group.  Streams do not group rows.
+	GroupBy []string
+	// Subtotals writes the totals of the aggregated columns beneath each group.
+	Subtotals bool
+	// SortBy is an ordered list of columns to sort array rows by.
+	SortBy []rowset.SortKey
+	// Offset is the number of array rows skipped, after sorting, before output starts.
+	Offset int
+	// Limit is the maximum number of array rows output, 0 for no limit.
+	// When Offset or Limit is set a trailer describing the rows shown is written beneath
+	// aligned, grid and markdown tables.
+	Limit int
+	// PageSize repeats the header every PageSize rows of aligned and grid output, 0 writes it once.
+	PageSize int
+	// StreamSampleRows is the number of rows an aligned or grid stream buffers to size
+	// its columns before output starts.  Later rows wider than a column are wrapped.
+	StreamSampleRows int
 }
 
 // NewOptions return new options.
 func NewOptions() Options {
 	return Options{
-		Style:           Aligned,
-		ColumnSeparator: "\t",
-		ColumnSet:       make(map[string]bool),
-		ExcludeSet:      make(map[string]bool),
+		Style:            Aligned,
+		ColumnSeparator:  "\t",
+		ColumnSet:        make(map[string]bool),
+		ExcludeSet:       make(map[string]bool),
+		ColumnColors:     make(map[string]Color),
+		CellColors:       make(map[string]map[string]Color),
+		StreamSampleRows: 100,
+		ListSeparator:    defaultListSeparator,
 	}
 }
 
-func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+func getOptions(options yaff.FormatOptions) (Options, error) {
 	if options == nil {
 		options = NewOptions()
 	}
@@ -68,13 +127,30 @@ func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ..
 	// convert options type
 	textOptions, ok := options.(Options)
 	if !ok {
-		return lpax.Errorf(langpack.ErrorInvalidOptionType, options, Text)
+		return textOptions, lpax.Errorf(langpack.ErrorInvalidOptionType, options, Text)
+	}
+
+	return normalizeOptions(textOptions), nil
+}
+
+func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	return f.FormatContext(context.Background(), writer, options, data...)
+}
+
+func (f *formatter) FormatContext(ctx context.Context, writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
+	textOptions, err := getOptions(options)
+	if err != nil {
+		return err
 	}
 
-	textOptions = normalizeOptions(textOptions)
+	// The terminal is detected before the writer is wrapped
+	textOptions = detectTerminal(writer, textOptions)
+
+	// Writes stop as soon as the context is done
+	writer = yaff.NewContextWriter(ctx, writer)
 
 	for _, d := range data {
-		if err := renderStyledText(writer, d, textOptions); err != nil {
+		if err := renderStyledText(ctx, writer, d, textOptions); err != nil {
 			return err
 		}
 	}
@@ -101,15 +177,60 @@ func normalizeOptions(options Options) Options {
 
 	options.ExcludeSet = xSet
 	options.ColumnSet = cSet
+
+	// Color lookups are by lower case column name too
+	colors := make(map[string]Color)
+	for k, v := range options.ColumnColors {
+		colors[strings.ToLower(k)] = v
+	}
+
+	cellColors := make(map[string]map[string]Color)
+	for k, v := range options.CellColors {
+		cellColors[strings.ToLower(k)] = v
+	}
+
+	options.ColumnColors = colors
+	options.CellColors = cellColors
+
+	aggregates := make(map[string]rowset.Aggregate)
+	for k, v := range options.Aggregates {
+		aggregates[strings.ToLower(k)] = v
+	}
+	options.Aggregates = aggregates
+
 	return options
 }
 
-func renderStyledText(writer io.Writer, d interface{}, options Options) error {
+// newStyledTabular creates a table using the column and color options.
+func newStyledTabular(options Options) *tabular {
 	table := newTabular(options.ColumnSet, options.ExcludeSet)
+	table.colors = newColorScheme(options)
+	table.sortBy = options.SortBy
+	table.columnSpecs = options.Columns
+	table.options = options
+	table.aggregates = options.Aggregates
+	table.groupBy = options.GroupBy
+	table.subtotals = options.Subtotals
+	table.nilPlaceholder = options.NilText
+	table.page = rowset.Page{Offset: options.Offset, Limit: options.Limit}
+	table.pageSize = options.PageSize
+	if options.ListSeparator != "" {
+		table.listSeparator = options.ListSeparator
+	}
+	return table
+}
+
+func renderStyledText(ctx context.Context, writer io.Writer, d interface{}, options Options) error {
+	table := newStyledTabular(options)
 
 	value := reflect.ValueOf(d)
 
-	if err := reflectInterface(table, value); err != nil {
+	// Vertical output writes a struct as a single record
+	if options.Style == Vertical {
+		value = asRecords(value)
+	}
+
+	if err := reflectInterface(ctx, table, value); err != nil {
 		return err
 	}
 
@@ -119,4 +240,5 @@ func renderStyledText(writer io.Writer, d interface{}, options Options) error {
 func init() {
 	// Register this formatter.
 	yaff.Formatters().Register(Text, NewFormatter)
+	yaff.Formatters().RegisterDecoder(Text, NewDecoder)
 }
@@ -18,10 +18,13 @@ package textformatter
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/nehemming/testsupport"
 	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/rowset"
 )
 
 func TestText(t *testing.T) {
@@ -416,3 +419,143 @@ func TestNewFormatterGridOptionWithWrapping(t *testing.T) {
 
 	testsupport.CompareStrings(t, expected, got)
 }
+
+func TestFormatContextCancelled(t *testing.T) {
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+
+	err = f.(yaff.ContextFormatter).FormatContext(ctx, &buf, nil, []testData{
+		{S: "Hello", I: 10, F: 3.14, N: innerData{Sin: "Inside"}},
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestNewFormatterSortBy(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+	options.SortBy = rowset.ParseSortKeys("-F,S")
+
+	err = fmt.Format(&buf, options, []testData{
+		{S: "Hello", I: 10, F: 3.14, N: innerData{Sin: "Inside"}},
+		{S: "Bye", I: 32, F: 12.5, N: innerData{Sin: "Outside"}},
+		{S: "Again", I: 1, F: 3.14, N: innerData{Sin: "Between"}},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `S,I,F,Sun
+Bye,32,12.5,Outside
+Again,1,3.14,Between
+Hello,10,3.14,Inside
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewFormatterSortByUnknown(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.SortBy = rowset.ParseSortKeys("Missing")
+
+	err = fmt.Format(&bytes.Buffer{}, options, []testData{{S: "Hello"}})
+	if err == nil {
+		t.Error("No unknown column error")
+	}
+}
+
+func TestNewFormatterColumns(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Grid
+	options.Columns = rowset.ParseColumnSpecs("N.Sun as Where,s,F")
+
+	err = fmt.Format(&buf, options, []testData{
+		{S: "Hello", I: 10, F: 3.14, N: innerData{Sin: "Inside"}},
+		{S: "Bye", I: 32, F: 12.5, N: innerData{Sin: "Outside"}},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `+---------+-------+------+
+| Where   | S     |    F |
++---------+-------+------+
+| Inside  | Hello | 3.14 |
++---------+-------+------+
+| Outside | Bye   | 12.5 |
++---------+-------+------+
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewFormatterColumnsDetail(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Plain
+	options.ColumnSeparator = ","
+	options.Columns = rowset.ParseColumnSpecs("B2 as Flag,S")
+
+	err = fmt.Format(&buf, options, testData2{S: "Hello"})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `Name,Output
+Flag,
+S,Hello
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewFormatterColumnsUnknown(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Columns = rowset.ParseColumnSpecs("S,Missing")
+
+	err = fmt.Format(&bytes.Buffer{}, options, []testData{{S: "Hello"}})
+	if err == nil {
+		t.Error("No unknown column error")
+	}
+}
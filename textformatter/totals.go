@@ -0,0 +1,173 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+// aggParamName is the name of the tag param used to total a column, i.e. agg=sum.
+const aggParamName = "agg"
+
+// columnTotal returns the accumulator of an aggregated column, or nil if the column is not aggregated.
+// The aggregates option takes precedence over the agg tag param, numeric aggregates need a numeric column.
+func (tablet *tabular) columnTotal(name string, numeric bool, tagInfo *tagData) (*rowset.Accumulator, error) {
+	agg, ok := tablet.aggregates[strings.ToLower(name)]
+	if !ok && tagInfo != nil {
+		var err error
+		if agg, err = rowset.ParseAggregate(tagInfo.Params[aggParamName]); err != nil {
+			return nil, err
+		}
+	}
+
+	if agg == rowset.NoAggregate {
+		return nil, nil
+	}
+
+	if agg.IsNumeric() && !numeric {
+		return nil, lpax.Errorf(langpack.ErrorAggregateNotNumeric, name, agg)
+	}
+
+	return rowset.NewAccumulator(agg), nil
+}
+
+// accumulate adds the value of a cell to its column total.  The values are kept
+// when rows are grouped with subtotals.
+func (tablet *tabular) accumulate(row rowID, col colID, v reflect.Value) {
+	total := tablet.columns[col].total
+	if total == nil {
+		return
+	}
+
+	total.Add(v)
+
+	if !tablet.subtotals || len(tablet.groupBy) == 0 {
+		return
+	}
+
+	for len(tablet.cells) <= int(row) {
+		tablet.cells = append(tablet.cells, nil)
+	}
+
+	if tablet.cells[row] == nil {
+		tablet.cells[row] = make([]reflect.Value, len(tablet.columns))
+	}
+
+	tablet.cells[row][col] = v
+}
+
+// footer returns the totals row of the table, or nil if no column is aggregated.
+func (tablet *tabular) footer() []string {
+	totals := make([]*rowset.Accumulator, len(tablet.columns))
+	for i, col := range tablet.columns {
+		totals[i] = col.total
+	}
+
+	label := ""
+	if tablet.subtotals && len(tablet.groupBy) > 0 {
+		label = lpax.Sprintf(langpack.TextTotal)
+	}
+
+	return tablet.totalsRow(totals, label, 0)
+}
+
+// totalsRow returns a row of the results of the column accumulators, or nil if there are none.
+// The first column from labelCol that is not aggregated is labelled, by default with the aggregate.
+func (tablet *tabular) totalsRow(totals []*rowset.Accumulator, label string, labelCol int) []string {
+	var aggregates []rowset.Aggregate
+
+	for _, total := range totals {
+		if total != nil {
+			aggregates = append(aggregates, total.Aggregate())
+		}
+	}
+
+	if len(aggregates) == 0 {
+		return nil
+	}
+
+	row := make([]string, len(tablet.columns))
+
+	for i, total := range totals {
+		if total == nil {
+			continue
+		}
+
+		result, ok := total.Result()
+
+		switch {
+		case !ok:
+		case total.Aggregate() == rowset.Count:
+			row[i] = fmt.Sprint(result.Interface())
+		case total.Aggregate() == rowset.Avg && result.Type() != total.Type():
+			row[i] = averageText(result.Float(), tablet.columns[i].tagInfo)
+		default:
+			row[i] = tablet.valueText(result, tablet.columns[i].tagInfo)
+		}
+	}
+
+	if label == "" {
+		label = rowset.Label(aggregates...)
+	}
+
+	for c := labelCol; c < len(row); c++ {
+		if totals[c] == nil {
+			row[c] = label
+			break
+		}
+	}
+
+	// Widen the columns to fit the totals unless they are already fixed
+	for i, field := range row {
+		if w := displayWidth(field); !tablet.sized && tablet.columns[i].width < w {
+			tablet.columns[i].width = w
+		}
+	}
+
+	return row
+}
+
+// averageText returns the text of the fractional average of an integer column using the column format.
+// Integer format verbs are given the rounded average, without a format it is output to two decimal places.
+func averageText(n float64, tagInfo *tagData) string {
+	var f valueFormat
+	if tagInfo != nil && tagInfo.format != nil {
+		f = *tagInfo.format
+	}
+
+	if isIntegerVerb(f.verb) {
+		return fmt.Sprintf(f.verb, int64(math.Round(n)))
+	}
+
+	if text, ok := f.apply(reflect.ValueOf(n)); ok {
+		return text
+	}
+
+	return f.number(n, "")
+}
+
+// isIntegerVerb returns true if the format verb only formats integers.
+func isIntegerVerb(verb string) bool {
+	return verb != "" && strings.ContainsAny(verb[len(verb)-1:], "bcdoOqxXU")
+}
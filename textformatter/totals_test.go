@@ -0,0 +1,210 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+)
+
+type volumeData struct {
+	Name  string
+	Size  int64         `tabular:",agg=sum,unit=bytes"`
+	Load  float64       `tabular:",agg=avg,format=%.1f"`
+	Taken time.Duration `tabular:",agg=max,duration=short"`
+}
+
+func volumeRows() []volumeData {
+	return []volumeData{
+		{Name: "a", Size: 1024, Load: 1, Taken: time.Minute},
+		{Name: "bb", Size: 2048, Load: 2, Taken: 90 * time.Second},
+	}
+}
+
+func formatStyle(t *testing.T, options Options, data interface{}) string {
+	t.Helper()
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestTotalsGrid(t *testing.T) {
+	options := NewOptions()
+	options.Style = LightBox
+
+	got := formatStyle(t, options, volumeRows())
+
+	expected := `┌───────┬─────────┬──────┬───────┐
+│ Name  │    Size │ Load │ Taken │
+├───────┼─────────┼──────┼───────┤
+│ a     │ 1.0 KiB │  1.0 │ 1m    │
+├───────┼─────────┼──────┼───────┤
+│ bb    │ 2.0 KiB │  2.0 │ 1m30s │
+├───────┼─────────┼──────┼───────┤
+│ Total │ 3.0 KiB │  1.5 │ 1m30s │
+└───────┴─────────┴──────┴───────┘
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestTotalsAlignedOptions(t *testing.T) {
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"name": rowset.Count, "SIZE": rowset.Min}
+	options.ExcludeSet = map[string]bool{"load": true, "taken": true}
+
+	got := formatStyle(t, options, volumeRows())
+
+	expected := `Name    Size
+a    1.0 KiB
+bb   2.0 KiB
+2    1.0 KiB
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestTotalsMarkdown(t *testing.T) {
+	options := NewOptions()
+	options.Style = Markdown
+	options.ColumnSet = map[string]bool{"name": true, "load": true}
+
+	got := formatStyle(t, options, volumeRows())
+
+	expected := `|Name|Load|
+|-|-|
+|a|1.0|
+|bb|2.0|
+|Avg|1.5|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestTotalsLabelColumn(t *testing.T) {
+	options := NewOptions()
+	options.Style = Markdown
+	options.Columns = rowset.ParseColumnSpecs("Size,Load,Name")
+
+	got := formatStyle(t, options, volumeRows())
+
+	expected := `|Size|Load|Name|
+|-|-|-|
+|1.0 KiB|1.0|a|
+|2.0 KiB|2.0|bb|
+|3.0 KiB|1.5|Total|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestTotalsAverageFormat(t *testing.T) {
+	type loadData struct {
+		Name  string
+		Count int   `tabular:",agg=avg"`
+		Peak  int   `tabular:",agg=avg,format=%03d"`
+		Size  int64 `tabular:",agg=avg,unit=bytes"`
+	}
+
+	options := NewOptions()
+	options.Style = Markdown
+
+	got := formatStyle(t, options, []loadData{{"a", 1, 5, 1024}, {"b", 1, 6, 1024}, {"c", 2, 6, 4096}})
+
+	expected := `|Name|Count|Peak|Size|
+|-|-|-|-|
+|a|1|005|1.0 KiB|
+|b|1|006|1.0 KiB|
+|c|2|006|4.0 KiB|
+|Avg|1.33|006|2.0 KiB|
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestTotalsPlain(t *testing.T) {
+	got := formatPlain(t, NewOptions(), volumeRows()[:1])
+
+	testsupport.CompareStrings(t, "Name,Size,Load,Taken\na,1.0 KiB,1.0,1m\n", got)
+}
+
+func TestTotalsStream(t *testing.T) {
+	options := NewOptions()
+	options.Style = Grid
+	options.StreamSampleRows = 1
+	options.ExcludeSet = map[string]bool{"size": true, "taken": true}
+
+	rows := volumeRows()
+	got := streamRows(t, options, rows[0], rows[1])
+
+	expected := `+------+------+
+| Name | Load |
++------+------+
+| a    |  1.0 |
++------+------+
+| bb   |  2.0 |
++------+------+
+| Avg  |  1.5 |
++------+------+
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestTotalsNotNumeric(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	options := NewOptions()
+	options.Aggregates = map[string]rowset.Aggregate{"name": rowset.Sum}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, options, volumeRows())
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorAggregateNotNumeric, "Name", rowset.Sum) {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestTotalsUnknownAggregate(t *testing.T) {
+	type badData struct {
+		Size int `tabular:",agg=median"`
+	}
+
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	err = fmt.Format(&buf, NewOptions(), []badData{{Size: 1}})
+	if err == nil || err.Error() != lpax.Sprintf(langpack.ErrorUnknownAggregate, "median") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/rowset"
+)
+
+const (
+	// nilParamName is the name of the tag param used to set the text of nil values.
+	nilParamName = "nil"
+
+	// sepParamName is the name of the tag param used to set the separator of list items.
+	sepParamName = "sep"
+
+	// defaultListSeparator separates list items when no separator is set.
+	defaultListSeparator = ", "
+)
+
+// fieldLayout is how a struct field is output.
+type fieldLayout int
+
+const (
+	// layoutNone fields are not output.
+	layoutNone = fieldLayout(iota)
+	// layoutColumn fields are output as a single column.
+	layoutColumn
+	// layoutFlatten fields are structs whose fields are output as columns.
+	layoutFlatten
+)
+
+// layoutOf returns how a field of type t is output and, for flattened fields, the struct type.
+// Pointers to structs are flattened unless the struct is already being flattened,
+// in which case they are output as a column, and slices of structs are not output.
+func layoutOf(t reflect.Type, flattening map[reflect.Type]bool) (fieldLayout, reflect.Type) {
+	switch t.Kind() {
+	case reflect.Func:
+		return layoutNone, nil
+
+	case reflect.Struct, reflect.Ptr:
+		if !isStructType(t) {
+			return layoutColumn, nil
+		}
+
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		// Recursive types are output as a value
+		if flattening[t] {
+			return layoutColumn, nil
+		}
+
+		return layoutFlatten, t
+
+	case reflect.Slice, reflect.Array:
+		if isStructType(t.Elem()) {
+			return layoutNone, nil
+		}
+		return layoutColumn, nil
+
+	default:
+		return layoutColumn, nil
+	}
+}
+
+// isRightAligned returns true if a column of type t is right aligned, strings, composite values and values that
+// format their own text are left aligned.
+func isRightAligned(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if rowset.HasCellText(t) {
+		return false
+	}
+
+	return t.Kind() != reflect.String && t.Kind() != reflect.Interface && !isCompositeKind(t.Kind())
+}
+
+// isCellValue returns true if a row of type t is output as a single value using its own text.
+// Structs that format their own text are still tabulated when they have fields that are output,
+// the text is only used for the fields and list items they are found in.
+func isCellValue(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if !rowset.HasCellText(t) {
+		return false
+	}
+
+	return t.Kind() != reflect.Struct || len(layoutFor(t).fields) == 0
+}
+
+// isNumeric returns true if t, or the type it points to, is a number kind.
+func isNumeric(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	_, ok := rowset.Number(reflect.Zero(t))
+	return ok
+}
+
+// isCompositeKind returns true for slices, arrays and maps.
+func isCompositeKind(k reflect.Kind) bool {
+	return k == reflect.Slice || k == reflect.Array || k == reflect.Map
+}
+
+// nilText returns the text of nil values, the nil tag param takes precedence over the table setting.
+func (tablet *tabular) nilText(tagInfo *tagData) string {
+	if tagInfo != nil {
+		if text, ok := tagInfo.Params[nilParamName]; ok {
+			return text
+		}
+	}
+	return tablet.nilPlaceholder
+}
+
+// separator returns the list item separator, the sep tag param takes precedence over the table setting.
+func (tablet *tabular) separator(tagInfo *tagData) string {
+	if tagInfo != nil {
+		if sep, ok := tagInfo.Params[sepParamName]; ok {
+			return sep
+		}
+	}
+	return tablet.listSeparator
+}
+
+// setCell sets a field to the text of a value, applying any alignment hint to the column and
+// adding the value to any column total.
+func (tablet *tabular) setCell(row rowID, col colID, v reflect.Value, tagInfo *tagData) error {
+	text, align := tablet.cellValue(v, tagInfo)
+
+	if err := tablet.setField(row, col, "%s", text); err != nil {
+		return err
+	}
+
+	tablet.accumulate(row, col, v)
+
+	tablet.alignColumn(col, align)
+
+	return nil
+}
+
+// alignColumn applies an alignment hint to a column.
+func (tablet *tabular) alignColumn(col colID, align yaff.Alignment) {
+	switch align {
+	case yaff.AlignLeft:
+		tablet.columns[col].rightAlign = false
+	case yaff.AlignRight:
+		tablet.columns[col].rightAlign = true
+	}
+}
+
+// valueText returns the text of a value.
+func (tablet *tabular) valueText(v reflect.Value, tagInfo *tagData) string {
+	text, _ := tablet.cellValue(v, tagInfo)
+	return text
+}
+
+// cellValue returns the text of a value and its alignment hint.  Pointers and interfaces are followed
+// to their value, tag format settings take precedence and values implementing yaff.CellFormatter,
+// fmt.Stringer or encoding.TextMarshaler format themselves.  The items of slices and arrays are joined
+// by the list separator and maps are output as key=value items in key order, with each item formatted.
+func (tablet *tabular) cellValue(v reflect.Value, tagInfo *tagData) (string, yaff.Alignment) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return tablet.nilText(tagInfo), yaff.AlignDefault
+	}
+
+	if !isCompositeKind(v.Kind()) {
+		if text, ok := tagInfo.formatValue(v); ok {
+			return text, yaff.AlignDefault
+		}
+	}
+
+	if text, align, ok := rowset.CellText(v); ok {
+		return text, align
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return tablet.nilText(tagInfo), yaff.AlignDefault
+		}
+
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = tablet.valueText(v.Index(i), tagInfo)
+		}
+
+		return strings.Join(items, tablet.separator(tagInfo)), yaff.AlignDefault
+
+	case reflect.Map:
+		if v.IsNil() {
+			return tablet.nilText(tagInfo), yaff.AlignDefault
+		}
+
+		keys := rowset.SortedKeys(v)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			items[i] = fmt.Sprintf("%v=%s", key.Interface(), tablet.valueText(v.MapIndex(key), tagInfo))
+		}
+
+		return strings.Join(items, tablet.separator(tagInfo)), yaff.AlignDefault
+
+	case reflect.Func:
+		return "", yaff.AlignDefault
+
+	default:
+		return fmt.Sprintf("%v", v.Interface()), yaff.AlignDefault
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nehemming/testsupport"
+)
+
+type nodeData struct {
+	Name string
+	Next *nodeData
+}
+
+type compositeData struct {
+	Name    string
+	Count   *int `tabular:",nil=none"`
+	When    *time.Time
+	Tags    []string `tabular:",sep=|"`
+	Sizes   [2]int
+	Payload interface{}
+	Labels  map[string]int
+	Node    *nodeData
+	Nodes   []nodeData
+	Func    func()
+}
+
+func compositeRows() []compositeData {
+	count := 3
+	when := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	return []compositeData{
+		{
+			Name: "full", Count: &count, When: &when, Tags: []string{"a", "b"}, Sizes: [2]int{1, 2},
+			Payload: &count, Labels: map[string]int{"y": 2, "x": 1}, Node: &nodeData{Name: "n1", Next: &nodeData{}},
+		},
+		{Name: "empty", Payload: []interface{}{"z", nil}},
+	}
+}
+
+func TestCompositeFields(t *testing.T) {
+	options := NewOptions()
+	options.NilText = "-"
+
+	got := formatPlain(t, options, compositeRows())
+
+	expected := `Name,Count,When,Tags,Sizes,Payload,Labels,Name,Next
+full,3,2021-03-04 05:06:07 +0000 UTC,a|b,1, 2,3,x=1, y=2,n1,{ <nil>}
+empty,none,-,-,0, 0,z, -,-,-,-
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestCompositeFieldsListSeparator(t *testing.T) {
+	options := NewOptions()
+	options.ListSeparator = "/"
+	options.ColumnSet = map[string]bool{"sizes": true, "labels": true}
+
+	got := formatPlain(t, options, compositeRows()[:1])
+
+	testsupport.CompareStrings(t, "Sizes,Labels\n1/2,x=1/y=2\n", got)
+}
+
+func TestCompositeFieldsDetail(t *testing.T) {
+	options := NewOptions()
+	options.ExcludeSet = map[string]bool{"when": true, "sizes": true}
+
+	got := formatPlain(t, options, compositeRows()[1])
+
+	expected := `Name,Output
+Name,empty
+Count,none
+Tags,
+Payload,z, 
+Node,
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestCompositeFieldsRecursiveType(t *testing.T) {
+	got := formatPlain(t, NewOptions(), []nodeData{{Name: "a", Next: &nodeData{Name: "b"}}, {Name: "c"}})
+
+	expected := `Name,Next
+a,{b <nil>}
+c,
+`
+	testsupport.CompareStrings(t, expected, got)
+}
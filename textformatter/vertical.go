@@ -0,0 +1,156 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff/langpack"
+)
+
+// verticalWriter writes each row as a record of name and value lines.
+type verticalWriter struct {
+	tablet     *tabular
+	out        io.Writer
+	nameWidth  int
+	valueWidth int
+	// wrapWidth is the width values are wrapped to, 0 if they are not wrapped.
+	wrapWidth     int
+	terminalWidth int
+}
+
+// newVerticalWriter sizes the records using the rows currently held by the table.
+func (tablet *tabular) newVerticalWriter(out io.Writer, terminalWidth int) *verticalWriter {
+	vw := &verticalWriter{tablet: tablet, out: out, terminalWidth: terminalWidth}
+
+	for _, col := range tablet.columns {
+		if w := displayWidth(col.name); w > vw.nameWidth {
+			vw.nameWidth = w
+		}
+	}
+
+	for _, row := range tablet.rows {
+		for _, field := range row {
+			if w := displayWidth(field); w > vw.valueWidth {
+				vw.valueWidth = w
+			}
+		}
+	}
+
+	if terminalWidth > 0 {
+		vw.wrapWidth = terminalWidth - vw.nameWidth - 3
+		if vw.wrapWidth < 1 {
+			vw.wrapWidth = 1
+		}
+
+		if vw.valueWidth > vw.wrapWidth {
+			vw.valueWidth = vw.wrapWidth
+		}
+	}
+
+	return vw
+}
+
+// recordLine returns the line written above a record, i.e. -[ RECORD 1 ]-+------.
+func (vw *verticalWriter) recordLine(record int) string {
+	line := "-[ " + lpax.Sprintf(langpack.TextRecord, record) + " ]"
+	w := displayWidth(line)
+
+	if w <= vw.nameWidth {
+		return line + strings.Repeat("-", vw.nameWidth+1-w) + "+" + strings.Repeat("-", vw.valueWidth+1) + "\n"
+	}
+
+	fill := vw.nameWidth + vw.valueWidth + 3 - w
+	if fill < 1 {
+		fill = 1
+	}
+
+	return line + strings.Repeat("-", fill) + "\n"
+}
+
+// writeRecord writes a row as a numbered record followed by its nested tables.
+func (vw *verticalWriter) writeRecord(record int, row []string, nested []nestedTable) error {
+	tablet := vw.tablet
+	colors := tablet.cellColors(row)
+	headerColor := tablet.colors.headerColor()
+
+	var b strings.Builder
+	b.WriteString(vw.recordLine(record))
+
+	for i, field := range row {
+		name := tablet.columns[i].name
+
+		lines := []string{field}
+		if vw.wrapWidth > 0 && displayWidth(field) > vw.wrapWidth {
+			lines = wrapText(field, vw.wrapWidth)
+		}
+
+		for _, line := range lines {
+			b.WriteString(headerColor.apply(name) + fillWidth(name, vw.nameWidth))
+
+			if line == "" {
+				b.WriteString(" |\n")
+			} else {
+				b.WriteString(" | " + colors[i].apply(line) + "\n")
+			}
+
+			// Wrapped lines are written beneath the value
+			name = ""
+		}
+	}
+
+	if _, err := io.WriteString(vw.out, b.String()); err != nil {
+		return err
+	}
+
+	return writeNested(vw.out, nested, Vertical, vw.terminalWidth)
+}
+
+// writeVertical writes each row as a record, grouped rows are output in group order.
+// The footer is not written.
+func (tablet *tabular) writeVertical(out io.Writer, terminalWidth int) error {
+	if _, err := tablet.groupRows(false); err != nil {
+		return err
+	}
+
+	vw := tablet.newVerticalWriter(out, terminalWidth)
+
+	for i, row := range tablet.rows {
+		if err := vw.writeRecord(i+1, row, tablet.nested[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// asRecords returns a struct as a single item slice so it is written as one vertical record,
+// other values are returned as is.
+func asRecords(value reflect.Value) reflect.Value {
+	if value.Kind() == reflect.Ptr {
+		value = reflect.Indirect(value)
+	}
+
+	if value.Kind() != reflect.Struct {
+		return value
+	}
+
+	return reflect.Append(reflect.MakeSlice(reflect.SliceOf(value.Type()), 0, 1), value)
+}
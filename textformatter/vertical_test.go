@@ -0,0 +1,102 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"testing"
+
+	"github.com/nehemming/testsupport"
+)
+
+type recordPart struct {
+	IntOne int
+	IntTwo int
+}
+
+type recordData struct {
+	Name     string
+	Status   string
+	Embedded recordPart
+	Note     string
+}
+
+func recordRows() []recordData {
+	return []recordData{
+		{Name: "alpha", Status: "ok", Embedded: recordPart{1, 2}, Note: "first"},
+		{Name: "beta", Status: "failed", Embedded: recordPart{3, 4}},
+	}
+}
+
+func TestVertical(t *testing.T) {
+	options := NewOptions()
+	options.Style = Vertical
+	options.ExcludeSet = map[string]bool{"Status": true}
+
+	got := formatStyle(t, options, recordRows())
+
+	expected := `-[ RECORD 1 ]-
+Name   | alpha
+IntOne | 1
+IntTwo | 2
+Note   | first
+-[ RECORD 2 ]-
+Name   | beta
+IntOne | 3
+IntTwo | 4
+Note   |
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestVerticalStructWrapped(t *testing.T) {
+	options := NewOptions()
+	options.Style = Vertical
+	options.ColumnSet = map[string]bool{"Description": true, "Status": true}
+	options.TerminalWidth = 26
+
+	got := formatStyle(t, options, &struct {
+		Status      string
+		Description string
+	}{"ok", "a long description that wraps"})
+
+	expected := `-[ RECORD 1 ]-------------
+Status      | ok
+Description | a long
+            | description
+            | that wraps
+`
+	testsupport.CompareStrings(t, expected, got)
+}
+
+func TestVerticalStream(t *testing.T) {
+	options := NewOptions()
+	options.Style = Vertical
+	options.Limit = 1
+
+	rows := recordRows()
+	got := streamRows(t, options, rows[0], rows[1])
+
+	expected := `-[ RECORD 1 ]-
+Name   | alpha
+Status | ok
+IntOne | 1
+IntTwo | 2
+Note   | first
+Showing rows 1 to 1 of 2
+`
+	testsupport.CompareStrings(t, expected, got)
+}
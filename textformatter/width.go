@@ -0,0 +1,148 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// displayWidth returns the number of terminal cells needed to display s.
+// East Asian wide and full width runes occupy two cells, combining marks and
+// control characters and ANSI escape sequences occupy none.
+func displayWidth(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w += runeWidth(r)
+		i += size
+	}
+	return w
+}
+
+// runeWidth returns the number of terminal cells used to display r.
+func runeWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7f && r < 0xa0) {
+		return 0
+	}
+
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// fillWidth returns the spaces needed to fill text out to the cell width w.
+func fillWidth(text string, w int) string {
+	fill := w - displayWidth(text)
+	if fill <= 0 {
+		return ""
+	}
+	return strings.Repeat(" ", fill)
+}
+
+// wrapText word wraps text into lines no wider than limit display cells.
+// Words wider than the line are broken across lines.
+func wrapText(text string, limit int) []string {
+	if limit < 1 {
+		limit = 1
+	}
+
+	words := breakWords(strings.Fields(text), limit)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	var line strings.Builder
+	used := 0
+
+	for _, word := range words {
+		w := displayWidth(word)
+
+		// Words after the first on a line are separated by a space
+		if line.Len() > 0 && used+1+w > limit {
+			lines = append(lines, line.String())
+			line.Reset()
+			used = 0
+		}
+
+		if line.Len() > 0 {
+			line.WriteString(" ")
+			used++
+		}
+		line.WriteString(word)
+		used += w
+	}
+
+	return append(lines, line.String())
+}
+
+// breakWords splits any word wider than limit into limit sized parts.
+func breakWords(words []string, limit int) []string {
+	result := make([]string, 0, len(words))
+
+	for _, word := range words {
+		if displayWidth(word) <= limit {
+			result = append(result, word)
+			continue
+		}
+
+		var part strings.Builder
+		partWidth := 0
+
+		for i := 0; i < len(word); {
+			// Escape sequences are kept whole and take no space
+			if n := escapeLen(word[i:]); n > 0 {
+				part.WriteString(word[i : i+n])
+				i += n
+				continue
+			}
+
+			r, size := utf8.DecodeRuneInString(word[i:])
+			rw := runeWidth(r)
+			if partWidth > 0 && partWidth+rw > limit {
+				result = append(result, part.String())
+				part.Reset()
+				partWidth = 0
+			}
+			part.WriteRune(r)
+			partWidth += rw
+			i += size
+		}
+
+		if part.Len() > 0 {
+			result = append(result, part.String())
+		}
+	}
+
+	return result
+}
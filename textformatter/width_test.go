@@ -0,0 +1,119 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package textformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	cases := map[string]int{
+		"":           0,
+		"hello":      5,
+		"café":       4,
+		"cafe\u0301": 4,
+		"日本語":        6,
+		"ｈｉ":         4,
+		"😀":          2,
+		"a\tb":       2,
+	}
+
+	for s, expected := range cases {
+		if w := displayWidth(s); w != expected {
+			t.Errorf("displayWidth(%q) = %d, expected %d", s, w, expected)
+		}
+	}
+}
+
+func TestWrapTextWide(t *testing.T) {
+	lines := wrapText("日本語 テキスト", 8)
+
+	if len(lines) != 2 || lines[0] != "日本語" || lines[1] != "テキスト" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
+
+func TestWrapTextBreaksWideWords(t *testing.T) {
+	lines := wrapText("日本語テキスト", 4)
+
+	if len(lines) != 4 || lines[0] != "日本" || lines[3] != "ト" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
+
+func TestWrapTextFullLine(t *testing.T) {
+	lines := wrapText("abc defghi", 10)
+
+	if len(lines) != 1 || lines[0] != "abc defghi" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+
+	lines = wrapText("abc defghij kl", 10)
+
+	if len(lines) != 2 || lines[0] != "abc" || lines[1] != "defghij kl" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
+
+func TestWrapTextEmpty(t *testing.T) {
+	lines := wrapText("", 4)
+
+	if len(lines) != 1 || lines[0] != "" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
+
+type wideData struct {
+	Name  string `tabular:"名前"`
+	Count int
+}
+
+func TestNewFormatterGridWideCharacters(t *testing.T) {
+	fmt, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	options := NewOptions()
+	options.Style = Grid
+
+	err = fmt.Format(&buf, options, []wideData{
+		{Name: "東京", Count: 1},
+		{Name: "Zürich", Count: 22},
+		{Name: "café", Count: 3},
+	})
+	if err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `+--------+-------+
+| 名前   | Count |
++--------+-------+
+| 東京   |     1 |
++--------+-------+
+| Zürich |    22 |
++--------+-------+
+| café   |     3 |
++--------+-------+
+`
+	testsupport.CompareStrings(t, expected, buf.String())
+}
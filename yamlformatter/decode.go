@@ -0,0 +1,163 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlformatter
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/rowset"
+	"gopkg.in/yaml.v3"
+)
+
+// NewDecoder return a new decoder reading YAML documents.
+func NewDecoder() (yaff.Decoder, error) {
+	return &decoder{}, nil
+}
+
+type decoder struct{}
+
+// errorLine matches the line number yaml includes in syntax errors.
+var errorLine = regexp.MustCompile(`line (\d+)`)
+
+// Decode reads each document of the input.  Sequences are added to a slice target a record per item,
+// unless each record is itself a list.  If the Envelope option is set the records are taken from the
+// items of each document.
+func (d *decoder) Decode(reader io.Reader, options yaff.FormatOptions, target interface{}) error {
+	yamlOptions := getOptions(options)
+
+	t, err := rowset.NewTarget(target)
+	if err != nil {
+		return err
+	}
+
+	dec := yaml.NewDecoder(reader)
+
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return lineError(err)
+		}
+
+		node := &doc
+		if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+			node = node.Content[0]
+		}
+
+		if yamlOptions.Envelope {
+			if node = items(node); node == nil {
+				continue
+			}
+		}
+
+		if err := decodeNode(node, t); err != nil {
+			return err
+		}
+	}
+}
+
+// items returns the value of the items key of a mapping.
+func items(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "items" {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// decodeNode decodes a node into the target, adding the items of a sequence unless the records are lists.
+func decodeNode(node *yaml.Node, t *rowset.Target) error {
+	nodes := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode && !isListType(t.Type()) {
+		nodes = node.Content
+	}
+
+	for _, n := range nodes {
+		record := t.New()
+		if err := n.Decode(record.Addr().Interface()); err != nil {
+			return nodeError(n, err)
+		}
+
+		t.Add(record)
+	}
+
+	return nil
+}
+
+// nodeError adds the position of the value a record could not be decoded from to the error.  Type errors
+// name the line of the value, its column is found within the record, otherwise the record position is used.
+func nodeError(n *yaml.Node, err error) error {
+	var typeError *yaml.TypeError
+
+	match := errorLine.FindStringSubmatch(err.Error())
+	if !errors.As(err, &typeError) || match == nil {
+		return &yaff.DecodeError{Line: n.Line, Column: n.Column, Err: err}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	return &yaff.DecodeError{Line: line, Column: valueColumn(n, line), Err: err}
+}
+
+// valueColumn returns the column of the last node on the line within the tree of n, the value of any key
+// on the line, or 0 if there is none.
+func valueColumn(n *yaml.Node, line int) int {
+	column := 0
+	if n.Line == line {
+		column = n.Column
+	}
+
+	for _, c := range n.Content {
+		if col := valueColumn(c, line); col > column {
+			column = col
+		}
+	}
+
+	return column
+}
+
+// isListType returns true if values of t, or the type it points to, are output as YAML sequences.
+func isListType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
+
+// lineError adds the line number found in a yaml error message to the error.
+func lineError(err error) error {
+	match := errorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	return &yaff.DecodeError{Line: line, Err: err}
+}
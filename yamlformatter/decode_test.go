@@ -0,0 +1,96 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlformatter
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nehemming/yaff"
+)
+
+func decodeRows() []testData {
+	return []testData{
+		{S: "a", I: 1, F: 1.5, N: innerData{Sin: "x"}},
+		{S: "b", I: 2},
+	}
+}
+
+func roundTrip(t *testing.T, options yaff.FormatOptions, target interface{}, data ...interface{}) {
+	t.Helper()
+
+	fmt, _ := NewFormatter()
+
+	var buf bytes.Buffer
+	if err := fmt.Format(&buf, options, data...); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	dec, err := yaff.Formatters().GetDecoder(YAML)
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	if err := dec.Decode(&buf, options, target); err != nil {
+		t.Errorf("Decode Error %v", err)
+	}
+}
+
+func TestDecodeMultiDoc(t *testing.T) {
+	var got []testData
+	roundTrip(t, nil, &got, decodeRows(), decodeRows()[0])
+
+	exp := append(decodeRows(), decodeRows()[0])
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %v expected %v", got, exp)
+	}
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	options := NewOptions()
+	options.Envelope = true
+	options.Limit = 1
+
+	var got []*testData
+	roundTrip(t, options, &got, decodeRows())
+
+	if len(got) != 1 || *got[0] != decodeRows()[0] {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	dec, _ := NewDecoder()
+
+	for input, exp := range map[string][2]int{
+		"- s: a\n- s: b\n  i: x\n":                   {3, 6},
+		"- s: a\n\t- s: b\n":                         {2, 0},
+		"s: a\n---\ns: b\n---\ns: c\ni: x\n":         {6, 4},
+		"- s: a\n---\n- s: b\n---\n- s: c\n  i: x\n": {6, 6},
+	} {
+		var got []testData
+		err := dec.Decode(strings.NewReader(input), nil, &got)
+
+		var decodeError *yaff.DecodeError
+		if !errors.As(err, &decodeError) || decodeError.Line != exp[0] || decodeError.Column != exp[1] {
+			t.Errorf("input %q err %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlformatter
+
+import (
+	"io"
+
+	"github.com/nehemming/lpax"
+	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/langpack"
+	"github.com/nehemming/yaff/rowset"
+	"gopkg.in/yaml.v3"
+)
+
+// stream writes each row as a separate document.
+type stream struct {
+	writer io.Writer
+	page   rowset.Page
+	rows   int
+	// seen is the number of rows received, including those outside the page.
+	seen   int
+	closed bool
+}
+
+func (f *formatter) NewStream(writer io.Writer, options yaff.FormatOptions) (yaff.Stream, error) {
+	return &stream{writer: writer, page: getOptions(options).page()}, nil
+}
+
+func (s *stream) Write(row interface{}) error {
+	if s.closed {
+		return lpax.Errorf(langpack.ErrorStreamClosed)
+	}
+
+	s.seen++
+	if !s.page.Includes(s.seen - 1) {
+		return nil
+	}
+
+	buf, err := yaml.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	// Separate from the previous document
+	if s.rows > 0 {
+		if _, err = s.writer.Write([]byte("\n---\n")); err != nil {
+			return err
+		}
+	}
+
+	s.rows++
+
+	_, err = s.writer.Write(buf)
+	return err
+}
+
+func (s *stream) Close() error {
+	if s.closed || s.rows == 0 {
+		s.closed = true
+		return nil
+	}
+	s.closed = true
+
+	// final new line
+	_, err := s.writer.Write([]byte("\n"))
+	return err
+}
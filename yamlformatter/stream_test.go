@@ -0,0 +1,66 @@
+/*
+Copyright (c) 2020-2021 The yaff Authors (Neil Hemming)
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlformatter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nehemming/testsupport"
+	"github.com/nehemming/yaff"
+)
+
+func TestStreamMatchesFormat(t *testing.T) {
+	rows := []interface{}{
+		&testData{S: "Hello", I: 10, F: 3.14, N: innerData{Sin: "Inside"}},
+		&testData{S: "Again", I: 99, F: 2.71},
+	}
+
+	f, err := NewFormatter()
+	if err != nil {
+		t.Errorf("Error %v", err)
+	}
+
+	var expected bytes.Buffer
+	if err := f.Format(&expected, nil, rows...); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	stream, err := f.(yaff.StreamFormatter).NewStream(&buf, nil)
+	if err != nil {
+		t.Errorf("Stream Error %v", err)
+		return
+	}
+
+	for _, row := range rows {
+		if err := stream.Write(row); err != nil {
+			t.Errorf("Write Error %v", err)
+		}
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, expected.String(), buf.String())
+
+	if err := stream.Write(rows[0]); err == nil {
+		t.Error("No closed error")
+	}
+}
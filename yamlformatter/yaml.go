@@ -21,6 +21,7 @@ import (
 	"io"
 
 	"github.com/nehemming/yaff"
+	"github.com/nehemming/yaff/rowset"
 	"gopkg.in/yaml.v3"
 )
 
@@ -34,17 +35,47 @@ func NewFormatter() (yaff.Formatter, error) {
 
 type formatter struct{}
 
+// Options for the YAML formatter.
+type Options struct {
+	// Offset is the number of rows skipped before output starts.
+	Offset int
+	// Limit is the maximum number of rows output, 0 for no limit.
+	Limit int
+	// Envelope wraps the rows in a mapping holding their total, offset and the items output.
+	// Streams are never wrapped.
+	Envelope bool
+}
+
+// NewOptions return new options.
+func NewOptions() Options {
+	return Options{}
+}
+
+// getOptions returns the YAML options, options of other types are ignored and the defaults used.
+func getOptions(options yaff.FormatOptions) Options {
+	if yamlOptions, ok := options.(Options); ok {
+		return yamlOptions
+	}
+
+	return NewOptions()
+}
+
+// page returns the rows selected by the options.
+func (options Options) page() rowset.Page {
+	return rowset.Page{Offset: options.Offset, Limit: options.Limit}
+}
+
 func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ...interface{}) error {
-	var err error
+	docs := getOptions(options).documents(data)
 
-	n := len(data)
+	n := len(docs)
 
 	if n > 0 { // will need a final new line
 		//nolint:errcheck
 		defer writer.Write([]byte("\n"))
 	}
 
-	for _, d := range data {
+	for _, d := range docs {
 		buf, err := yaml.Marshal(d)
 		if err != nil {
 			return err
@@ -65,10 +96,34 @@ func (f *formatter) Format(writer io.Writer, options yaff.FormatOptions, data ..
 		}
 	}
 
-	return err
+	return nil
+}
+
+// documents returns the documents output for the data.  As with JSON the rows paged are those of a single
+// data item or, when there are several, the data items themselves, which are output as separate documents
+// unless wrapped in an envelope.
+func (options Options) documents(data []interface{}) []interface{} {
+	page := options.page()
+
+	switch {
+	case len(data) == 1 && options.Envelope:
+		return []interface{}{page.Envelope(data[0])}
+
+	case len(data) == 1:
+		d, _ := page.Apply(data[0])
+		return []interface{}{d}
+
+	case options.Envelope:
+		return []interface{}{page.Envelope(data)}
+
+	default:
+		start, end := page.Window(len(data))
+		return data[start:end]
+	}
 }
 
 func init() {
 	// Register this formatter
 	yaff.Formatters().Register(YAML, NewFormatter)
+	yaff.Formatters().RegisterDecoder(YAML, NewDecoder)
 }
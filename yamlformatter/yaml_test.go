@@ -116,3 +116,72 @@ f: 2.71
 
 	testsupport.CompareStrings(t, expected, got)
 }
+
+func TestNewFormatterEnvelope(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Offset = 1
+	options.Limit = 1
+	options.Envelope = true
+
+	var buf bytes.Buffer
+
+	if err := fmt.Format(&buf, options, []string{"a", "b", "c"}); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `total: 3
+offset: 1
+items:
+    - b
+
+`
+
+	testsupport.CompareStrings(t, expected, buf.String())
+}
+
+func TestNewFormatterOtherOptions(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	var buf bytes.Buffer
+
+	if err := fmt.Format(&buf, "bad", "a"); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "a\n\n", buf.String())
+}
+
+func TestNewFormatterPagesDocuments(t *testing.T) {
+	fmt, _ := NewFormatter()
+
+	options := NewOptions()
+	options.Offset = 1
+	options.Limit = 2
+
+	var buf bytes.Buffer
+
+	if err := fmt.Format(&buf, options, []string{"a", "b"}, "c", []string{"d"}, "e"); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	testsupport.CompareStrings(t, "c\n\n---\n- d\n\n", buf.String())
+
+	buf.Reset()
+	options.Envelope = true
+
+	if err := fmt.Format(&buf, options, "a", "b", "c", "d"); err != nil {
+		t.Errorf("Formatter Error %v", err)
+	}
+
+	expected := `total: 4
+offset: 1
+items:
+    - b
+    - c
+
+`
+
+	testsupport.CompareStrings(t, expected, buf.String())
+}